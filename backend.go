@@ -2,14 +2,20 @@ package dbsecretengine
 
 import (
 	"DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/internal/notify"
+	"DatabasePluginVault/storage"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/queue"
 )
 
 const (
@@ -23,42 +29,191 @@ const (
 // connectionManager caches and tears down Engine instances.
 type connectionManager struct {
 	mu      sync.RWMutex
-	engines map[string]Engine.Engine
+	engines map[string]*trackedEngine
+
+	// pending holds the constructions started by GetOrCreate so concurrent
+	// callers for the same name wait for one Engine instead of each
+	// building their own.
+	pending map[string]*pendingEngine
+}
+
+// pendingEngine is an Engine construction in progress.
+type pendingEngine struct {
+	done chan struct{}
+	eng  Engine.Engine
+	err  error
+
+	// stale is set when the connection is cleared during construction, as
+	// the Engine may have been built from the config being replaced.
+	stale bool
 }
 
 func newConnectionManager() *connectionManager {
 	return &connectionManager{
-		engines: make(map[string]Engine.Engine),
+		engines: make(map[string]*trackedEngine),
+		pending: make(map[string]*pendingEngine),
+	}
+}
+
+// GetOrCreate returns the cached Engine for name, calling create to build
+// and cache one if there is none. Concurrent callers for the same name share
+// a single call to create.
+func (m *connectionManager) GetOrCreate(name string, create func() (Engine.Engine, error)) (Engine.Engine, error) {
+	m.mu.Lock()
+	if eng, ok := m.engines[name]; ok {
+		m.mu.Unlock()
+		return eng, nil
+	}
+	if p, ok := m.pending[name]; ok {
+		m.mu.Unlock()
+		<-p.done
+		return p.eng, p.err
+	}
+	p := &pendingEngine{done: make(chan struct{})}
+	m.pending[name] = p
+	m.mu.Unlock()
+
+	eng, err := create()
+
+	m.mu.Lock()
+	delete(m.pending, name)
+	switch {
+	case err != nil:
+		p.err = err
+	case p.stale:
+		eng.Close()
+		p.err = errEngineClosed
+	case m.engines[name] != nil:
+		// A Put landed while we were building; it wins.
+		eng.Close()
+		p.eng = m.engines[name]
+	default:
+		t := trackEngine(eng)
+		m.engines[name] = t
+		p.eng = t
 	}
+	m.mu.Unlock()
+	close(p.done)
+	return p.eng, p.err
 }
 
-// Put stores a new Engine under name, returning any old one.
+// Put stores a new Engine under name, returning any old one. Closing the
+// old Engine waits for the operations still running on it.
 func (m *connectionManager) Put(name string, eng Engine.Engine) (old Engine.Engine) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	old = m.engines[name]
-	m.engines[name] = eng
+	if prev, ok := m.engines[name]; ok {
+		old = prev
+	}
+	m.engines[name] = trackEngine(eng)
 	return old
 }
 
-// ClearConnection closes and removes the Engine for name.
+// ClearConnection closes and removes the Engine for name. An Engine still
+// being built for name is discarded once built.
 func (m *connectionManager) ClearConnection(name string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	if eng, ok := m.engines[name]; ok {
+	eng, ok := m.engines[name]
+	delete(m.engines, name)
+	if p, pending := m.pending[name]; pending {
+		p.stale = true
+	}
+	m.mu.Unlock()
+	if ok {
 		eng.Close()
-		delete(m.engines, name)
 	}
 }
 
 // ClearAll closes and removes *all* Engines (used on unmount).
 func (m *connectionManager) ClearAll() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	for _, eng := range m.engines {
+	engines := m.engines
+	m.engines = make(map[string]*trackedEngine)
+	for _, p := range m.pending {
+		p.stale = true
+	}
+	m.mu.Unlock()
+	for _, eng := range engines {
 		eng.Close()
 	}
-	m.engines = make(map[string]Engine.Engine)
+}
+
+// errEngineClosed is returned by operations started on an Engine that has
+// been swapped out and closed.
+var errEngineClosed = errors.New("connection was reset, retry the request")
+
+// trackedEngine counts the operations running on an Engine so Close can
+// wait for them before tearing the pool down.
+type trackedEngine struct {
+	Engine.Engine
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func trackEngine(eng Engine.Engine) *trackedEngine {
+	if t, ok := eng.(*trackedEngine); ok {
+		return t
+	}
+	return &trackedEngine{Engine: eng}
+}
+
+// acquire marks an operation as running. It must be paired with release.
+func (t *trackedEngine) acquire() error {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return errEngineClosed
+	}
+	return nil
+}
+
+func (t *trackedEngine) release() {
+	t.mu.RUnlock()
+}
+
+func (t *trackedEngine) Connect(ctx context.Context) (*sql.DB, error) {
+	if err := t.acquire(); err != nil {
+		return nil, err
+	}
+	defer t.release()
+	return t.Engine.Connect(ctx)
+}
+
+func (t *trackedEngine) NewUser(ctx context.Context, req Engine.NewUserRequest) error {
+	if err := t.acquire(); err != nil {
+		return err
+	}
+	defer t.release()
+	return t.Engine.NewUser(ctx, req)
+}
+
+func (t *trackedEngine) UpdateUser(ctx context.Context, req Engine.UpdateUserRequest) error {
+	if err := t.acquire(); err != nil {
+		return err
+	}
+	defer t.release()
+	return t.Engine.UpdateUser(ctx, req)
+}
+
+func (t *trackedEngine) DeleteUser(ctx context.Context, req Engine.DeleteUserRequest) error {
+	if err := t.acquire(); err != nil {
+		return err
+	}
+	defer t.release()
+	return t.Engine.DeleteUser(ctx, req)
+}
+
+// Close waits for running operations to finish, then closes the Engine.
+// Later operations fail with errEngineClosed.
+func (t *trackedEngine) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	return t.Engine.Close()
 }
 
 // databaseBackend is the Vault logical backend.
@@ -66,6 +221,21 @@ type databaseBackend struct {
 	*framework.Backend
 	conn *connectionManager
 
+	// queue holds static roles ordered by their next rotation time.
+	queue *queue.PriorityQueue
+
+	// newNotifier builds the notifier for rotation mails from the stored
+	// SMTP config.
+	newNotifier func(*notify.SMTPConfig) (notify.Notifier, error)
+
+	// roleLocks serialise loading, rotating and storing a static role so
+	// concurrent rotations cannot overwrite each other's password.
+	roleLocks []*locksutil.LockEntry
+
+	// connLocks serialise writes to a connection config, including root
+	// rotations, so none of them stores a stale password.
+	connLocks []*locksutil.LockEntry
+
 	logger log.Logger
 }
 
@@ -87,7 +257,11 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 
 func Backend(conf *logical.BackendConfig) *databaseBackend {
 	b := &databaseBackend{
-		conn: newConnectionManager(),
+		conn:        newConnectionManager(),
+		queue:       queue.New(),
+		newNotifier: newSMTPNotifier,
+		roleLocks:   locksutil.CreateLocks(),
+		connLocks:   locksutil.CreateLocks(),
 	}
 	b.Backend = &framework.Backend{
 		Help:        backendHelp,
@@ -98,12 +272,38 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			},
 			SealWrapStorage: []string{
 				"config",
+				storage.SMTPConfigPath,
+				// roles may hold a credential_config.ca_private_key
+				storage.RolePrefix,
+				// static roles hold the managed password
+				staticRolePrefix,
 			},
 		},
-		Secrets: []*framework.Secret{},
+		Secrets: []*framework.Secret{
+			secretCreds(b),
+		},
 		Paths: framework.PathAppend(
 			pathConfigurePluginConnection(b),
+			PathRoles(b),
+			[]*framework.Path{
+				PathConfigSMTP(b),
+				PathStaticRoles(b),
+				PathStaticRoleList(b),
+				PathStaticRoleListAll(b),
+				PathStaticCreds(b),
+				PathRotateRole(b),
+				PathRotateRoot(b),
+				PathCreds(b),
+			},
 		),
+		// InitializeFunc rebuilds the rotation queue from storage on mount.
+		InitializeFunc: b.initQueue,
+		// PeriodicFunc rotates static roles that are due.
+		PeriodicFunc: b.periodicFunc,
+		// WALRollback recovers rotations interrupted between the database
+		// change and the storage write.
+		WALRollback:       b.walRollback,
+		WALRollbackMinAge: walRollbackMinAge,
 		// Clean is called when the backend is unmounted; shut everything down.
 		Clean: b.clean,
 		// Invalidate is called when any storage key changes; used to clear a single entry.
@@ -114,9 +314,21 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 }
 
 const backendHelp = `
-MySQL-only Database Secrets Engine (clean refactor).
+Database Secrets Engine for MySQL and PostgreSQL, with a SQLite engine for
+local development (clean refactor).
+
+Configure connection info via the config/<name> endpoint and manage
+existing database users via static-roles/<db_type>/<name>. Applications
+read the managed credential from static-creds/<db_type>/<name>.
+
+Dynamic roles are configured via roles/<name>; creds/<name> issues a
+short-lived database user under a lease.
+
+reset/<name> rebuilds a connection's pool from its stored config, for
+example after a failover or certificate change.
 
-Configure connection info via the config/<name> endpoint.
+Rotation results are mailed to each connection's emails once an SMTP
+server is configured via smtp/config.
 `
 
 // clean tears down every Engine instance (called on unmount).
@@ -0,0 +1,189 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/dbengines/Engine"
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeEngine is an Engine that only records whether it was closed.
+type fakeEngine struct {
+	closed atomic.Bool
+}
+
+func (e *fakeEngine) Connect(ctx context.Context) (*sql.DB, error) {
+	return nil, nil
+}
+
+func (e *fakeEngine) Close() error {
+	e.closed.Store(true)
+	return nil
+}
+
+func (e *fakeEngine) NewUser(ctx context.Context, req Engine.NewUserRequest) error {
+	return nil
+}
+
+func (e *fakeEngine) UpdateUser(ctx context.Context, req Engine.UpdateUserRequest) error {
+	return nil
+}
+
+func (e *fakeEngine) DeleteUser(ctx context.Context, req Engine.DeleteUserRequest) error {
+	return nil
+}
+
+func (e *fakeEngine) MaxUsernameLength() int {
+	return 63
+}
+
+func (e *fakeEngine) Metadata() Engine.Metadata {
+	return Engine.Metadata{Name: "fake"}
+}
+
+// blockingCreate is a create func for GetOrCreate that counts its calls and
+// blocks until release is closed.
+type blockingCreate struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	built   *fakeEngine
+}
+
+func newBlockingCreate() *blockingCreate {
+	return &blockingCreate{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		built:   &fakeEngine{},
+	}
+}
+
+func (c *blockingCreate) create() (Engine.Engine, error) {
+	if c.calls.Add(1) == 1 {
+		close(c.started)
+	}
+	<-c.release
+	return c.built, nil
+}
+
+// unwrap returns the Engine inside a trackedEngine.
+func unwrap(t *testing.T, eng Engine.Engine) Engine.Engine {
+	t.Helper()
+	te, ok := eng.(*trackedEngine)
+	if !ok {
+		t.Fatalf("expected a *trackedEngine, got %T", eng)
+	}
+	return te.Engine
+}
+
+func TestConnectionManager_GetOrCreateSharesConstruction(t *testing.T) {
+	m := newConnectionManager()
+	c := newBlockingCreate()
+
+	const callers = 8
+	results := make([]Engine.Engine, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			eng, err := m.GetOrCreate("db", c.create)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = eng
+		}(i)
+	}
+	<-c.started
+	close(c.release)
+	wg.Wait()
+
+	if n := c.calls.Load(); n != 1 {
+		t.Fatalf("create called %d times, want 1", n)
+	}
+	for i, eng := range results {
+		if eng != results[0] {
+			t.Fatalf("caller %d got a different engine", i)
+		}
+	}
+	if unwrap(t, results[0]) != c.built {
+		t.Fatal("callers did not get the built engine")
+	}
+	if c.built.closed.Load() {
+		t.Fatal("cached engine was closed")
+	}
+
+	eng, err := m.GetOrCreate("db", c.create)
+	if err != nil || eng != results[0] || c.calls.Load() != 1 {
+		t.Fatalf("expected the cached engine, got %v, %v after %d calls", eng, err, c.calls.Load())
+	}
+}
+
+func TestConnectionManager_ClearDuringConstruction(t *testing.T) {
+	m := newConnectionManager()
+	c := newBlockingCreate()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := m.GetOrCreate("db", c.create)
+		errc <- err
+	}()
+	<-c.started
+	m.ClearConnection("db")
+	close(c.release)
+
+	if err := <-errc; err != errEngineClosed {
+		t.Fatalf("expected errEngineClosed, got %v", err)
+	}
+	if !c.built.closed.Load() {
+		t.Fatal("stale engine was not closed")
+	}
+
+	// The stale engine was not cached, so the next call builds a new one.
+	fresh := &fakeEngine{}
+	eng, err := m.GetOrCreate("db", func() (Engine.Engine, error) { return fresh, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unwrap(t, eng) != fresh {
+		t.Fatal("stale engine was cached")
+	}
+}
+
+func TestConnectionManager_PutDuringConstruction(t *testing.T) {
+	m := newConnectionManager()
+	c := newBlockingCreate()
+
+	type result struct {
+		eng Engine.Engine
+		err error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		eng, err := m.GetOrCreate("db", c.create)
+		resc <- result{eng, err}
+	}()
+	<-c.started
+	put := &fakeEngine{}
+	if old := m.Put("db", put); old != nil {
+		t.Fatalf("expected no previous engine, got %v", old)
+	}
+	close(c.release)
+
+	res := <-resc
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if unwrap(t, res.eng) != put {
+		t.Fatal("the engine from Put did not win")
+	}
+	if !c.built.closed.Load() {
+		t.Fatal("the losing engine was not closed")
+	}
+	if put.closed.Load() {
+		t.Fatal("the engine from Put was closed")
+	}
+}
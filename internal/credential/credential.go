@@ -0,0 +1,262 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// DefaultKeyBits is the RSA key size used when credential_config sets none.
+	DefaultKeyBits = 2048
+
+	// DefaultCertTTL is how long issued client certificates are valid when
+	// credential_config sets no ttl.
+	DefaultCertTTL = 24 * time.Hour
+)
+
+// RSAConfig is the credential_config of an rsa_private_key role.
+type RSAConfig struct {
+	KeyBits int `mapstructure:"key_bits"`
+}
+
+// CertConfig is the credential_config of a client_certificate role. CACert
+// and CAPrivateKey are the PEM encoded authority that signs issued certs.
+type CertConfig struct {
+	KeyBits      int           `mapstructure:"key_bits"`
+	TTL          time.Duration `mapstructure:"ttl"`
+	CACert       string        `mapstructure:"ca_cert"`
+	CAPrivateKey string        `mapstructure:"ca_private_key"`
+
+	ca    *x509.Certificate
+	caKey interface{}
+}
+
+// Validate checks that config is usable for credType, so role writes fail
+// early instead of at issue time.
+func Validate(credType dbplugin.CredentialType, config map[string]interface{}) error {
+	switch credType {
+	case dbplugin.CredentialTypePassword:
+		return nil
+	case dbplugin.CredentialTypeRSAPrivateKey:
+		_, err := ParseRSAConfig(config)
+		return err
+	case dbplugin.CredentialTypeClientCertificate:
+		_, err := ParseCertConfig(config)
+		return err
+	default:
+		return fmt.Errorf("unsupported credential_type %q", credType)
+	}
+}
+
+// ParseRSAConfig decodes and defaults an rsa_private_key credential_config.
+func ParseRSAConfig(raw map[string]interface{}) (*RSAConfig, error) {
+	var c RSAConfig
+	if err := mapstructure.WeakDecode(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid credential_config: %w", err)
+	}
+	bits, err := keyBits(c.KeyBits)
+	if err != nil {
+		return nil, err
+	}
+	c.KeyBits = bits
+	return &c, nil
+}
+
+// ParseCertConfig decodes, defaults and loads the CA of a client_certificate
+// credential_config.
+func ParseCertConfig(raw map[string]interface{}) (*CertConfig, error) {
+	var c CertConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		Result:           &c,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.Decode(raw); err != nil {
+		return nil, fmt.Errorf("invalid credential_config: %w", err)
+	}
+
+	if c.KeyBits, err = keyBits(c.KeyBits); err != nil {
+		return nil, err
+	}
+	if c.TTL <= 0 {
+		c.TTL = DefaultCertTTL
+	}
+
+	block, _ := pem.Decode([]byte(c.CACert))
+	if block == nil {
+		return nil, fmt.Errorf("credential_config.ca_cert must be a PEM encoded certificate")
+	}
+	if c.ca, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, fmt.Errorf("invalid credential_config.ca_cert: %w", err)
+	}
+	block, _ = pem.Decode([]byte(c.CAPrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("credential_config.ca_private_key must be a PEM encoded private key")
+	}
+	if c.caKey, err = parsePrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("invalid credential_config.ca_private_key: %w", err)
+	}
+	return &c, nil
+}
+
+// KeyPair is a generated RSA key pair in PEM form.
+type KeyPair struct {
+	PrivateKey []byte
+	PublicKey  []byte
+}
+
+// GenerateRSAKey returns a new PKCS#8 private key and its PKIX public key.
+func GenerateRSAKey(bits int) (*KeyPair, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	return encodeKeyPair(key)
+}
+
+// ClientCert is an issued client certificate with its key.
+type ClientCert struct {
+	Certificate []byte
+	PrivateKey  []byte
+	IssuingCA   []byte
+	// Subject and Issuer are in the "/C=../O=../CN=.." form MySQL's
+	// REQUIRE SUBJECT and REQUIRE ISSUER compare against.
+	Subject string
+	Issuer  string
+}
+
+// IssueClientCert signs a client certificate for commonName with the CA
+// from c.
+func (c *CertConfig) IssueClientCert(commonName string) (*ClientCert, error) {
+	key, err := rsa.GenerateKey(rand.Reader, c.KeyBits)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-30 * time.Second),
+		NotAfter:     now.Add(c.TTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, c.ca, &key.PublicKey, c.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign client certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := encodeKeyPair(key)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := onelineName(cert.RawSubject)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := onelineName(cert.RawIssuer)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCert{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKey:  pair.PrivateKey,
+		IssuingCA:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.ca.Raw}),
+		Subject:     subject,
+		Issuer:      issuer,
+	}, nil
+}
+
+func keyBits(bits int) (int, error) {
+	switch bits {
+	case 0:
+		return DefaultKeyBits, nil
+	case 2048, 3072, 4096:
+		return bits, nil
+	default:
+		return 0, fmt.Errorf("credential_config.key_bits must be 2048, 3072 or 4096")
+	}
+}
+
+func encodeKeyPair(key *rsa.PrivateKey) (*KeyPair, error) {
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		PrivateKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}),
+		PublicKey:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}),
+	}, nil
+}
+
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return x509.ParseECPrivateKey(der)
+}
+
+// attributeNames maps the OIDs MySQL prints by short name.
+var attributeNames = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "street",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.17":                   "postalCode",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"0.9.2342.19200300.100.1.25": "DC",
+}
+
+// onelineName renders a raw DER name in certificate order the way OpenSSL's
+// X509_NAME_oneline does.
+func onelineName(raw []byte) (string, error) {
+	var rdns pkix.RDNSequence
+	if _, err := asn1.Unmarshal(raw, &rdns); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, rdn := range rdns {
+		for _, atv := range rdn {
+			name, ok := attributeNames[atv.Type.String()]
+			if !ok {
+				name = atv.Type.String()
+			}
+			fmt.Fprintf(&sb, "/%s=%v", name, atv.Value)
+		}
+	}
+	return sb.String(), nil
+}
@@ -3,6 +3,11 @@ package Engine
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 )
 
 // Engine is the minimal interface your path handlers need.
@@ -13,7 +18,116 @@ type Engine interface {
 	Close() error
 
 	// Static‐role credential ops:
-	NewUser(ctx context.Context, username, password string) error
-	UpdateUser(ctx context.Context, username, password string) error
-	DeleteUser(ctx context.Context, username string) error
+	NewUser(ctx context.Context, req NewUserRequest) error
+	UpdateUser(ctx context.Context, req UpdateUserRequest) error
+	DeleteUser(ctx context.Context, req DeleteUserRequest) error
+
+	// MaxUsernameLength is the longest username the database accepts.
+	MaxUsernameLength() int
+
+	// Metadata describes the engine and the optional features it supports.
+	Metadata() Metadata
+}
+
+// ErrAuthentication is wrapped by Connect errors caused by the database
+// rejecting the configured credentials, as opposed to it being unreachable.
+var ErrAuthentication = errors.New("authentication failed")
+
+// Feature is an optional capability of an Engine.
+type Feature string
+
+const (
+	// FeatureDualPasswords means a rotation can keep the old password valid
+	// until the new one is in use.
+	FeatureDualPasswords Feature = "dual_passwords"
+	// FeatureTransactions means a request's statements run in one
+	// transaction, so a failure leaves the database unchanged.
+	FeatureTransactions Feature = "transactions"
+	// FeatureRSAPrivateKey means users can authenticate with a key pair.
+	FeatureRSAPrivateKey Feature = "rsa_private_key"
+	// FeatureClientCertificate means users can authenticate with a client
+	// certificate.
+	FeatureClientCertificate Feature = "client_certificate"
+	// FeatureCustomStatements means requests may replace the default
+	// statements.
+	FeatureCustomStatements Feature = "custom_statements"
+)
+
+// CredentialFeature returns the feature needed to issue the credential type.
+// Passwords are supported by every engine and need none.
+func CredentialFeature(t dbplugin.CredentialType) (Feature, bool) {
+	switch t {
+	case dbplugin.CredentialTypeRSAPrivateKey:
+		return FeatureRSAPrivateKey, true
+	case dbplugin.CredentialTypeClientCertificate:
+		return FeatureClientCertificate, true
+	}
+	return "", false
+}
+
+// Metadata describes an Engine.
+type Metadata struct {
+	Name     string
+	Version  string
+	Features []Feature
+}
+
+// Supports reports whether the engine has the feature.
+func (m Metadata) Supports(f Feature) bool {
+	for _, have := range m.Features {
+		if have == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Require returns an error naming the first feature the engine lacks.
+func (m Metadata) Require(features ...Feature) error {
+	for _, f := range features {
+		if !m.Supports(f) {
+			return fmt.Errorf("%s engine %s does not support %s", m.Name, m.Version, f)
+		}
+	}
+	return nil
+}
+
+// NewUserRequest describes a user to create. Statements are templates
+// rendered with {{name}}, {{password}} and {{expiration}}, plus
+// {{public_key}}, {{subject}} and {{issuer}} for the key based credential
+// types, and {{account}} for 'name'@'%' on MySQL; when empty the engine's
+// default creation statement is used.
+type NewUserRequest struct {
+	Username   string
+	Expiration time.Time
+	Statements []string
+
+	// CredentialType selects which credential below is set.
+	CredentialType dbplugin.CredentialType
+	Password       string
+	// PublicKey is the PEM public key for CredentialTypeRSAPrivateKey.
+	PublicKey []byte
+	// Subject and Issuer identify the client certificate for
+	// CredentialTypeClientCertificate.
+	Subject string
+	Issuer  string
+}
+
+// UpdateUserRequest describes a password change, or, when Password is empty,
+// a move of the user's expiry to Expiration on lease renewal. Statements are
+// templates rendered with {{name}}, {{password}} and {{expiration}}; when
+// empty the engine's default rotation or renewal statement is used.
+type UpdateUserRequest struct {
+	Username   string
+	Password   string
+	Expiration time.Time
+	Statements []string
+}
+
+// DeleteUserRequest describes a user to drop. Statements are templates
+// rendered with {{name}}; when empty the engine's default revocation
+// statement is used.
+type DeleteUserRequest struct {
+	Username   string
+	Statements []string
 }
@@ -0,0 +1,54 @@
+// Package dsn renders connection URL templates so credentials can be stored
+// apart from the URL and substituted only when a connection is opened.
+package dsn
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	// UsernameTemplate is replaced with the connection's username.
+	UsernameTemplate = "{{username}}"
+	// PasswordTemplate is replaced with the connection's password.
+	PasswordTemplate = "{{password}}"
+)
+
+// Escaper prepares a credential for the URL syntax it is substituted into.
+type Escaper func(string) string
+
+// Render substitutes the username and password into tmpl. The result holds
+// the plaintext password and must not be stored or logged.
+func Render(tmpl, username, password string, escape Escaper) string {
+	if escape == nil {
+		escape = Literal
+	}
+	return strings.NewReplacer(
+		UsernameTemplate, escape(username),
+		PasswordTemplate, escape(password),
+	).Replace(tmpl)
+}
+
+// IsTemplated reports whether tmpl contains a credential placeholder.
+func IsTemplated(tmpl string) bool {
+	return strings.Contains(tmpl, UsernameTemplate) || strings.Contains(tmpl, PasswordTemplate)
+}
+
+// Literal leaves s unchanged, for DSN formats that read credentials verbatim.
+func Literal(s string) string {
+	return s
+}
+
+// KeywordValue quotes s as a value of a libpq style key=value DSN, so
+// spaces, quotes and backslashes in it are read back unchanged.
+func KeywordValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// URLEscape escapes s for the userinfo of a URL. It is url.QueryEscape with
+// spaces as %20, since userinfo does not decode '+' as a space.
+func URLEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -1,6 +1,8 @@
 package mysql
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"time"
 
@@ -27,6 +29,8 @@ type Config struct {
 	TLSClientCert []byte `mapstructure:"tls_client_cert"`
 	TLSSkipVerify bool   `mapstructure:"tls_skip_verify"`
 	TLSServerName string `mapstructure:"tls_server_name"`
+	// TLSConfig is built from the fields above; nil when none are set.
+	TLSConfig *tls.Config `mapstructure:"-"`
 
 	// Preserve original config input
 	RawConfig map[string]interface{}
@@ -61,9 +65,47 @@ func Load(raw map[string]interface{}) (*Config, error) {
 	}
 	cfg.MaxConnectionLifetime = dur
 
+	tlsConfig, err := cfg.buildTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.TLSConfig = tlsConfig
+
 	return &cfg, nil
 }
 
+// buildTLSConfig turns the tls_* fields into a tls.Config, rejecting PEM
+// input that does not parse. It returns nil when no TLS field is set.
+func (c *Config) buildTLSConfig() (*tls.Config, error) {
+	if len(c.TLSCACert) == 0 && len(c.TLSClientCert) == 0 && len(c.TLSClientKey) == 0 &&
+		!c.TLSSkipVerify && c.TLSServerName == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.TLSSkipVerify,
+	}
+	if len(c.TLSCACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.TLSCACert) {
+			return nil, fmt.Errorf("tls_ca_cert does not contain a valid PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(c.TLSClientCert) > 0 || len(c.TLSClientKey) > 0 {
+		if len(c.TLSClientCert) == 0 || len(c.TLSClientKey) == 0 {
+			return nil, fmt.Errorf("tls_client_cert and tls_client_key must be set together")
+		}
+		cert, err := tls.X509KeyPair(c.TLSClientCert, c.TLSClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls_client_cert or tls_client_key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
 // Validate can be optionally reused to double-check if needed externally.
 func (c *Config) Validate() error {
 	if c.ConnectionURL == "" {
@@ -1,20 +1,33 @@
 package mysql
 
 import (
+	engine "DatabasePluginVault/internal/dbengines/Engine"
+	dsnutil "DatabasePluginVault/internal/dbengines/dsn"
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
-	// register the mysql driver
-	_ "github.com/go-sql-driver/mysql"
+
+	// also registers the mysql driver
+	"github.com/go-sql-driver/mysql"
 )
 
+// erAccessDenied is the server error for a rejected user or password.
+const erAccessDenied = 1045
+
 // MySQLDriver manages the *sql.DB pool.
 type MySQLDriver struct {
 	cfg *Config
 	mu  sync.Mutex
 	db  *sql.DB
+
+	// tlsKey is the name cfg.TLSConfig is registered under with the mysql
+	// driver, set while a registration is live.
+	tlsKey string
 }
 
 // NewConnectionProducer builds a driver from a typed Config.
@@ -36,7 +49,11 @@ func (d *MySQLDriver) Connect(ctx context.Context) (*sql.DB, error) {
 		d.db = nil
 	}
 	log.Print("Opening new connection")
-	db, err := sql.Open("mysql", d.cfg.ConnectionURL)
+	dsn, err := d.dsn()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		log.Print("Failed to open")
 		return nil, fmt.Errorf("mysql open: %w", err)
@@ -45,6 +62,10 @@ func (d *MySQLDriver) Connect(ctx context.Context) (*sql.DB, error) {
 	if err := db.PingContext(ctx); err != nil {
 		log.Print("Ping failed:", err)
 		db.Close()
+		var merr *mysql.MySQLError
+		if errors.As(err, &merr) && merr.Number == erAccessDenied {
+			return nil, fmt.Errorf("mysql ping: %w: %w", engine.ErrAuthentication, err)
+		}
 		return nil, fmt.Errorf("mysql ping: %w", err)
 	}
 	db.SetMaxOpenConns(d.cfg.MaxOpenConnections)
@@ -63,5 +84,50 @@ func (d *MySQLDriver) Close() error {
 		d.db.Close()
 		d.db = nil
 	}
+	if d.tlsKey != "" {
+		mysql.DeregisterTLSConfig(d.tlsKey)
+		d.tlsKey = ""
+	}
 	return nil
 }
+
+// dsn returns the connection URL to open. With TLS configured it registers
+// the tls.Config under a key unique to this driver and points the DSN's tls
+// parameter at it.
+//
+// {{username}} and {{password}} in connection_url are substituted verbatim:
+// the mysql DSN parser does not unescape credentials, and splits on the last
+// '@' so passwords need no escaping.
+func (d *MySQLDriver) dsn() (string, error) {
+	connURL := dsnutil.Render(d.cfg.ConnectionURL, d.cfg.Username, d.cfg.Password, dsnutil.Literal)
+	if d.cfg.TLSConfig == nil {
+		return connURL, nil
+	}
+	if d.tlsKey == "" {
+		key, err := newTLSKey()
+		if err != nil {
+			return "", err
+		}
+		if err := mysql.RegisterTLSConfig(key, d.cfg.TLSConfig.Clone()); err != nil {
+			return "", fmt.Errorf("mysql register tls config: %w", err)
+		}
+		d.tlsKey = key
+	}
+	// ParseDSN errors never include the DSN, so the password cannot leak.
+	dsnCfg, err := mysql.ParseDSN(connURL)
+	if err != nil {
+		return "", fmt.Errorf("mysql parse connection_url: %w", err)
+	}
+	dsnCfg.TLS = nil
+	dsnCfg.TLSConfig = d.tlsKey
+	return dsnCfg.FormatDSN(), nil
+}
+
+// newTLSKey returns a random name for a TLS config registration.
+func newTLSKey() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "vault-" + hex.EncodeToString(b), nil
+}
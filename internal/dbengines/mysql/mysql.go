@@ -2,11 +2,20 @@ package mysql
 
 import (
 	engine "DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/internal/dbengines/statements"
 	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 )
 
+// maxUsernameLength is MySQL's account name limit (5.7.8 and later).
+const maxUsernameLength = 32
+
+// version is the version of this engine reported in its metadata.
+const version = "1.0.0"
+
 // Engine implements dbengines.Engine for MySQL.
 type Engine struct {
 	driver *MySQLDriver
@@ -34,32 +43,81 @@ func (e *Engine) Close() error {
 	return e.driver.Close()
 }
 
-func (e *Engine) NewUser(ctx context.Context, username, password string) error {
+func (e *Engine) MaxUsernameLength() int {
+	return maxUsernameLength
+}
+
+func (e *Engine) Metadata() engine.Metadata {
+	return engine.Metadata{
+		Name:    "mysql",
+		Version: version,
+		// No FeatureTransactions: CREATE USER, ALTER USER and GRANT commit
+		// implicitly, so a failed statement cannot undo the earlier ones.
+		Features: []engine.Feature{
+			engine.FeatureClientCertificate,
+			engine.FeatureCustomStatements,
+		},
+	}
+}
+
+func (e *Engine) NewUser(ctx context.Context, req engine.NewUserRequest) error {
 	db, err := e.driver.Connect(ctx)
 	if err != nil {
 		return err
 	}
-	query := fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY '%s'", username, password)
-	_, err = db.ExecContext(ctx, query)
-	return err
+	v := statementValues{
+		name:       req.Username,
+		expiration: req.Expiration,
+	}
+	stmts := req.Statements
+	switch req.CredentialType {
+	case dbplugin.CredentialTypePassword:
+		v.password = req.Password
+		if len(stmts) == 0 {
+			stmts = []string{defaultCreationStatement}
+		}
+	case dbplugin.CredentialTypeClientCertificate:
+		v.subject, v.issuer = req.Subject, req.Issuer
+		if len(stmts) == 0 {
+			stmts = []string{defaultCertCreation}
+		}
+	default:
+		return fmt.Errorf("mysql does not support %s credentials", req.CredentialType)
+	}
+	return statements.Exec(ctx, db, stmts, v.render)
 }
 
-func (e *Engine) UpdateUser(ctx context.Context, username, password string) error {
+func (e *Engine) UpdateUser(ctx context.Context, req engine.UpdateUserRequest) error {
+	stmts := req.Statements
+	switch {
+	case len(stmts) > 0:
+	case req.Password != "":
+		stmts = []string{defaultRotationStatement}
+	default:
+		// MySQL accounts carry no expiry, so there is nothing to renew.
+		return nil
+	}
 	db, err := e.driver.Connect(ctx)
 	if err != nil {
 		return err
 	}
-	query := fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY '%s'", username, password)
-	_, err = db.ExecContext(ctx, query)
-	return err
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name:       req.Username,
+		password:   req.Password,
+		expiration: req.Expiration,
+	}.render)
 }
 
-func (e *Engine) DeleteUser(ctx context.Context, username string) error {
+func (e *Engine) DeleteUser(ctx context.Context, req engine.DeleteUserRequest) error {
 	db, err := e.driver.Connect(ctx)
 	if err != nil {
 		return err
 	}
-	query := fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%'", username)
-	_, err = db.ExecContext(ctx, query)
-	return err
+	stmts := req.Statements
+	if len(stmts) == 0 {
+		stmts = []string{defaultRevocationStatement}
+	}
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name: req.Username,
+	}.render)
 }
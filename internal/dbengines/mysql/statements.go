@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"DatabasePluginVault/internal/dbengines/quote"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCreationStatement   = `CREATE USER {{account}} IDENTIFIED BY '{{password}}';`
+	defaultCertCreation        = `CREATE USER {{account}} REQUIRE SUBJECT '{{subject}}' AND ISSUER '{{issuer}}';`
+	defaultRotationStatement   = `ALTER USER {{account}} IDENTIFIED BY '{{password}}';`
+	defaultRevocationStatement = `DROP USER IF EXISTS {{account}};`
+
+	// accountHost is the host part of {{account}}; accounts may connect
+	// from anywhere.
+	accountHost = "%"
+
+	// expirationFormat is how {{expiration}} is rendered.
+	expirationFormat = "2006-01-02 15:04:05"
+)
+
+// statementValues holds the values substituted into statement templates.
+type statementValues struct {
+	name       string
+	password   string
+	expiration time.Time
+	subject    string
+	issuer     string
+}
+
+// render substitutes the template variables in stmt. {{account}} expands to
+// the quoted account name; the other values are escaped so they are safe
+// inside the single-quoted literals templates put them in. Values with
+// control characters are rejected.
+func (v statementValues) render(stmt string) (string, error) {
+	account, err := quote.MySQL.Account(v.name, accountHost)
+	if err != nil {
+		return "", fmt.Errorf("invalid {{account}}: %w", err)
+	}
+	pairs := make([]string, 0, 14)
+	pairs = append(pairs, "{{account}}", account)
+	for _, p := range []struct{ key, value string }{
+		{"{{name}}", v.name},
+		{"{{username}}", v.name},
+		{"{{password}}", v.password},
+		{"{{subject}}", v.subject},
+		{"{{issuer}}", v.issuer},
+	} {
+		esc, err := quote.MySQL.EscapeLiteral(p.value)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", p.key, err)
+		}
+		pairs = append(pairs, p.key, esc)
+	}
+	if !v.expiration.IsZero() {
+		pairs = append(pairs, "{{expiration}}", v.expiration.UTC().Format(expirationFormat))
+	}
+	return strings.NewReplacer(pairs...).Replace(stmt), nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	engine "DatabasePluginVault/internal/dbengines/Engine"
+	dsnutil "DatabasePluginVault/internal/dbengines/dsn"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgconn"
+
+	// register the pgx driver as "pgx"
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+// SQLSTATE codes the server returns for rejected credentials.
+const (
+	sqlStateInvalidPassword      = "28P01"
+	sqlStateInvalidAuthorization = "28000"
+)
+
+// PostgresDriver manages the *sql.DB pool.
+type PostgresDriver struct {
+	cfg *Config
+	mu  sync.Mutex
+	db  *sql.DB
+}
+
+// NewConnectionProducer builds a driver from a typed Config.
+func NewConnectionProducer(cfg *Config) (*PostgresDriver, error) {
+	return &PostgresDriver{cfg: cfg}, nil
+}
+
+// Connect returns a cached *sql.DB or opens a new one.
+func (d *PostgresDriver) Connect(ctx context.Context) (*sql.DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db != nil {
+		if err := d.db.PingContext(ctx); err == nil {
+			return d.db, nil
+		}
+		d.db.Close()
+		d.db = nil
+	}
+	db, err := sql.Open("pgx", d.connectionURL())
+	if err != nil {
+		return nil, fmt.Errorf("postgresql open: %w", err)
+	}
+	// enforce an actual connect + auth step
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && (pgErr.Code == sqlStateInvalidPassword || pgErr.Code == sqlStateInvalidAuthorization) {
+			return nil, fmt.Errorf("postgresql ping: %w: %w", engine.ErrAuthentication, err)
+		}
+		return nil, fmt.Errorf("postgresql ping: %w", err)
+	}
+	db.SetMaxOpenConns(d.cfg.MaxOpenConnections)
+	db.SetMaxIdleConns(d.cfg.MaxIdleConnections)
+	db.SetConnMaxLifetime(d.cfg.MaxConnectionLifetime)
+	d.db = db
+	return db, nil
+}
+
+// connectionURL renders {{username}} and {{password}} into connection_url.
+// Credentials are URL escaped for postgres:// URLs and quoted for key=value
+// DSNs, where the placeholders must therefore not be quoted already.
+func (d *PostgresDriver) connectionURL() string {
+	escape := dsnutil.KeywordValue
+	if strings.Contains(d.cfg.ConnectionURL, "://") {
+		escape = dsnutil.URLEscape
+	}
+	return dsnutil.Render(d.cfg.ConnectionURL, d.cfg.Username, d.cfg.Password, escape)
+}
+
+// Close tears down the DB pool.
+func (d *PostgresDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db != nil {
+		d.db.Close()
+		d.db = nil
+	}
+	return nil
+}
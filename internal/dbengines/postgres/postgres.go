@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	engine "DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/internal/dbengines/statements"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+// maxUsernameLength is PostgreSQL's identifier limit (NAMEDATALEN - 1).
+const maxUsernameLength = 63
+
+// version is the version of this engine reported in its metadata.
+const version = "1.0.0"
+
+// Engine implements dbengines.Engine for PostgreSQL.
+type Engine struct {
+	driver *PostgresDriver
+}
+
+// NewEngine is used by the registry.
+func NewEngine(raw map[string]interface{}) (engine.Engine, error) {
+	cfg, err := Load(raw)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := NewConnectionProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{driver: driver}, nil
+}
+
+func (e *Engine) Connect(ctx context.Context) (*sql.DB, error) {
+	return e.driver.Connect(ctx)
+}
+
+func (e *Engine) Close() error {
+	return e.driver.Close()
+}
+
+func (e *Engine) MaxUsernameLength() int {
+	return maxUsernameLength
+}
+
+func (e *Engine) Metadata() engine.Metadata {
+	return engine.Metadata{
+		Name:    "postgresql",
+		Version: version,
+		Features: []engine.Feature{
+			engine.FeatureTransactions,
+			engine.FeatureCustomStatements,
+		},
+	}
+}
+
+func (e *Engine) NewUser(ctx context.Context, req engine.NewUserRequest) error {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return fmt.Errorf("postgresql does not support %s credentials", req.CredentialType)
+	}
+	db, err := e.driver.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	stmts := req.Statements
+	if len(stmts) == 0 {
+		stmts = []string{defaultCreationStatement}
+	}
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name:       req.Username,
+		password:   req.Password,
+		expiration: req.Expiration,
+	}.render)
+}
+
+func (e *Engine) UpdateUser(ctx context.Context, req engine.UpdateUserRequest) error {
+	db, err := e.driver.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	stmts := req.Statements
+	switch {
+	case len(stmts) > 0:
+	case req.Password != "":
+		stmts = []string{defaultRotationStatement}
+	default:
+		stmts = []string{defaultRenewStatement}
+	}
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name:       req.Username,
+		password:   req.Password,
+		expiration: req.Expiration,
+	}.render)
+}
+
+// DeleteUser drops the role. A role that no longer exists is not an error,
+// since the default statements cannot REVOKE from a missing role.
+func (e *Engine) DeleteUser(ctx context.Context, req engine.DeleteUserRequest) error {
+	db, err := e.driver.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", req.Username).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	stmts := req.Statements
+	if len(stmts) == 0 {
+		stmts = []string{defaultRevocationStatement}
+	}
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name: req.Username,
+	}.render)
+}
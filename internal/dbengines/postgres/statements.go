@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"DatabasePluginVault/internal/dbengines/quote"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCreationStatement   = `CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}';`
+	defaultRotationStatement   = `ALTER ROLE "{{name}}" WITH PASSWORD '{{password}}';`
+	defaultRenewStatement      = `ALTER ROLE "{{name}}" VALID UNTIL '{{expiration}}';`
+	defaultRevocationStatement = `REVOKE ALL PRIVILEGES ON ALL TABLES IN SCHEMA public FROM "{{name}}";
+REVOKE ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public FROM "{{name}}";
+REVOKE USAGE ON SCHEMA public FROM "{{name}}";
+DROP ROLE IF EXISTS "{{name}}";`
+
+	// expirationFormat is how {{expiration}} is rendered for VALID UNTIL.
+	expirationFormat = "2006-01-02 15:04:05-07"
+	// noExpiration is rendered for {{expiration}} when no expiry is set.
+	noExpiration = "infinity"
+)
+
+// statementValues holds the values substituted into statement templates.
+type statementValues struct {
+	name       string
+	password   string
+	expiration time.Time
+}
+
+// render substitutes the template variables in stmt. {{name}} and
+// {{username}} are escaped for the double-quoted identifiers templates put
+// them in, {{password}} for a single-quoted literal; values with control
+// characters are rejected.
+func (v statementValues) render(stmt string) (string, error) {
+	name, err := quote.PostgreSQL.EscapeIdentifier(v.name)
+	if err != nil {
+		return "", fmt.Errorf("invalid {{name}}: %w", err)
+	}
+	password, err := quote.PostgreSQL.EscapeLiteral(v.password)
+	if err != nil {
+		return "", fmt.Errorf("invalid {{password}}: %w", err)
+	}
+	expiration := noExpiration
+	if !v.expiration.IsZero() {
+		expiration = v.expiration.UTC().Format(expirationFormat)
+	}
+	return strings.NewReplacer(
+		"{{name}}", name,
+		"{{username}}", name,
+		"{{password}}", password,
+		"{{expiration}}", expiration,
+	).Replace(stmt), nil
+}
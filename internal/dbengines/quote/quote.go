@@ -0,0 +1,90 @@
+// Package quote escapes values for the SQL dialects the engines speak, so
+// usernames, passwords and certificate fields can be placed in statement
+// templates without changing the statement's meaning.
+package quote
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dialect describes how one database quotes identifiers and literals.
+type Dialect struct {
+	name string
+	// identQuote encloses identifiers and is doubled inside them.
+	identQuote string
+	// backslashEscapes is set when backslash is an escape character in
+	// string literals, as in MySQL's default sql_mode.
+	backslashEscapes bool
+	// hostAccounts is set when accounts are named 'user'@'host'.
+	hostAccounts bool
+}
+
+var (
+	// MySQL quotes identifiers with backticks and treats backslash as an
+	// escape in literals.
+	MySQL = Dialect{name: "mysql", identQuote: "`", backslashEscapes: true, hostAccounts: true}
+	// PostgreSQL quotes identifiers with double quotes and assumes
+	// standard_conforming_strings, the default since 9.1.
+	PostgreSQL = Dialect{name: "postgresql", identQuote: `"`}
+	// SQLite quotes identifiers with double quotes; literals have no escapes.
+	SQLite = Dialect{name: "sqlite", identQuote: `"`}
+)
+
+// Check rejects values no statement should carry: invalid UTF-8 and control
+// characters, including NUL and newlines.
+func (d Dialect) Check(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("%s: value is not valid UTF-8", d.name)
+	}
+	for i, r := range s {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%s: value contains control character %U at byte %d", d.name, r, i)
+		}
+	}
+	return nil
+}
+
+// EscapeIdentifier escapes s for use between the dialect's identifier
+// quotes, e.g. inside "{{name}}".
+func (d Dialect) EscapeIdentifier(s string) (string, error) {
+	if err := d.Check(s); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(s, d.identQuote, d.identQuote+d.identQuote), nil
+}
+
+// EscapeLiteral escapes s for use between single quotes, e.g. inside
+// '{{password}}'.
+func (d Dialect) EscapeLiteral(s string) (string, error) {
+	if err := d.Check(s); err != nil {
+		return "", err
+	}
+	if d.backslashEscapes {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return strings.ReplaceAll(s, `'`, `''`), nil
+}
+
+// Account returns the quoted name of a database account. MySQL accounts are
+// 'user'@'host'; the other dialects name roles by identifier and ignore host.
+func (d Dialect) Account(user, host string) (string, error) {
+	if !d.hostAccounts {
+		esc, err := d.EscapeIdentifier(user)
+		if err != nil {
+			return "", err
+		}
+		return d.identQuote + esc + d.identQuote, nil
+	}
+	u, err := d.EscapeLiteral(user)
+	if err != nil {
+		return "", err
+	}
+	h, err := d.EscapeLiteral(host)
+	if err != nil {
+		return "", err
+	}
+	return "'" + u + "'@'" + h + "'", nil
+}
@@ -0,0 +1,302 @@
+package quote
+
+import (
+	"DatabasePluginVault/internal/password"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+var dialects = []Dialect{MySQL, PostgreSQL, SQLite}
+
+var seeds = []string{
+	"",
+	"plain",
+	"'",
+	"''",
+	`\`,
+	`\'`,
+	`'\`,
+	`a'b\c"d` + "`e",
+	`"`,
+	"`",
+	"p@ss; DROP TABLE users; --",
+	"ünïcødé ✓",
+}
+
+// scanQuoted parses a token opened and closed by q from the start of s,
+// the way the dialect's lexer would. A doubled q stands for one q and, when
+// backslashEscapes is set, a backslash escapes the next byte. It returns the
+// unescaped value and the length of the token; ok is false when s holds no
+// closed token.
+func scanQuoted(s string, q byte, backslashEscapes bool) (value string, n int, ok bool) {
+	if len(s) == 0 || s[0] != q {
+		return "", 0, false
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case backslashEscapes && c == '\\':
+			if i+1 == len(s) {
+				return "", 0, false
+			}
+			i++
+			sb.WriteByte(s[i])
+		case c == q:
+			if i+1 < len(s) && s[i+1] == q {
+				i++
+				sb.WriteByte(q)
+				continue
+			}
+			return sb.String(), i + 1, true
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+// checkRoundTrip asserts that quoted, the escaped value between q, is
+// read back as want and that no quote in it ends the token early.
+func checkRoundTrip(t *testing.T, d Dialect, quoted string, q byte, backslashEscapes bool, want string) {
+	t.Helper()
+	got, n, ok := scanQuoted(quoted, q, backslashEscapes)
+	if !ok {
+		t.Fatalf("%s: %q is not a closed token", d.name, quoted)
+	}
+	if n != len(quoted) {
+		t.Fatalf("%s: %q ends at byte %d, escaping the token", d.name, quoted, n)
+	}
+	if got != want {
+		t.Fatalf("%s: %q reads back as %q, want %q", d.name, quoted, got, want)
+	}
+}
+
+// generatedPassword returns a password from the built-in generator.
+func generatedPassword(t testing.TB) string {
+	pw, err := password.Default(password.DefaultLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pw
+}
+
+// addSeeds adds the fixed seeds and a few generated passwords to the fuzz
+// corpus.
+func addSeeds(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	for i := 0; i < 8; i++ {
+		f.Add(generatedPassword(f))
+	}
+}
+
+func openSQLite(t testing.TB) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// selectLiteral checks that SQLite reads '<esc>' back as want. PostgreSQL
+// with standard_conforming_strings lexes literals the same way, so its
+// escaping is checked here too.
+func selectLiteral(t *testing.T, db *sql.DB, d Dialect, esc, want string) {
+	t.Helper()
+	var got string
+	if err := db.QueryRow("SELECT '" + esc + "'").Scan(&got); err != nil {
+		t.Fatalf("%s: SELECT of %q: %v", d.name, want, err)
+	}
+	if got != want {
+		t.Fatalf("%s: SELECT of %q returned %q", d.name, want, got)
+	}
+}
+
+// selectIdentifier checks that SQLite names the column "<esc>" want. As with
+// literals, PostgreSQL quotes identifiers the same way.
+func selectIdentifier(t *testing.T, db *sql.DB, d Dialect, esc, want string) {
+	t.Helper()
+	rows, err := db.Query(`SELECT 1 AS "` + esc + `"`)
+	if err != nil {
+		t.Fatalf("%s: SELECT AS %q: %v", d.name, want, err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 1 || cols[0] != want {
+		t.Fatalf("%s: column for %q is %q", d.name, want, cols)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, tc := range []struct {
+		d          Dialect
+		in         string
+		literal    string
+		identifier string
+	}{
+		{MySQL, "plain", "plain", "plain"},
+		{MySQL, "o'brien", "o''brien", "o'brien"},
+		{MySQL, `a\b`, `a\\b`, `a\b`},
+		{MySQL, `\'`, `\\''`, `\'`},
+		{MySQL, "back`tick", "back`tick", "back``tick"},
+		{MySQL, `dq"`, `dq"`, `dq"`},
+		{PostgreSQL, "o'brien", "o''brien", "o'brien"},
+		{PostgreSQL, `a\b`, `a\b`, `a\b`},
+		{PostgreSQL, `dq"`, `dq"`, `dq""`},
+		{PostgreSQL, "back`tick", "back`tick", "back`tick"},
+		{SQLite, `\'"`, `\''"`, `\'""`},
+	} {
+		lit, err := tc.d.EscapeLiteral(tc.in)
+		if err != nil || lit != tc.literal {
+			t.Errorf("%s: EscapeLiteral(%q) = %q, %v; want %q", tc.d.name, tc.in, lit, err, tc.literal)
+		}
+		id, err := tc.d.EscapeIdentifier(tc.in)
+		if err != nil || id != tc.identifier {
+			t.Errorf("%s: EscapeIdentifier(%q) = %q, %v; want %q", tc.d.name, tc.in, id, err, tc.identifier)
+		}
+	}
+}
+
+func TestAccount(t *testing.T) {
+	for _, tc := range []struct {
+		d          Dialect
+		user, host string
+		want       string
+	}{
+		{MySQL, "app", "%", `'app'@'%'`},
+		{MySQL, "o'brien", "10.0.0.%", `'o''brien'@'10.0.0.%'`},
+		{MySQL, `a\`, "h'", `'a\\'@'h'''`},
+		{PostgreSQL, "app", "%", `"app"`},
+		{PostgreSQL, `a"b`, "ignored", `"a""b"`},
+		{SQLite, `a"b`, "", `"a""b"`},
+	} {
+		got, err := tc.d.Account(tc.user, tc.host)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: Account(%q, %q) = %q, %v; want %q", tc.d.name, tc.user, tc.host, got, err, tc.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, s := range []string{"a\x00b", "line\nbreak", "tab\t", "\x7f", "\xff"} {
+		for _, d := range dialects {
+			if _, err := d.EscapeLiteral(s); err == nil {
+				t.Errorf("%s: EscapeLiteral accepted %q", d.name, s)
+			}
+			if _, err := d.EscapeIdentifier(s); err == nil {
+				t.Errorf("%s: EscapeIdentifier accepted %q", d.name, s)
+			}
+			if _, err := d.Account(s, "%"); err == nil {
+				t.Errorf("%s: Account accepted user %q", d.name, s)
+			}
+		}
+		if _, err := MySQL.Account("app", s); err == nil {
+			t.Errorf("mysql: Account accepted host %q", s)
+		}
+	}
+}
+
+// TestGeneratedPasswords round-trips passwords from the built-in generator
+// through every dialect, and through SQLite itself for the dialects that
+// share its lexer.
+func TestGeneratedPasswords(t *testing.T) {
+	db := openSQLite(t)
+	for i := 0; i < 500; i++ {
+		pw := generatedPassword(t)
+		for _, d := range dialects {
+			esc, err := d.EscapeLiteral(pw)
+			if err != nil {
+				t.Fatalf("%s: rejected generated password %q: %v", d.name, pw, err)
+			}
+			checkRoundTrip(t, d, "'"+esc+"'", '\'', d.backslashEscapes, pw)
+			if !d.backslashEscapes {
+				selectLiteral(t, db, d, esc, pw)
+			}
+		}
+	}
+}
+
+func FuzzEscapeLiteral(f *testing.F) {
+	addSeeds(f)
+	db := openSQLite(f)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, d := range dialects {
+			esc, err := d.EscapeLiteral(s)
+			if checkErr := d.Check(s); checkErr != nil {
+				if err == nil {
+					t.Fatalf("%s: accepted %q, which fails Check: %v", d.name, s, checkErr)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s: rejected %q: %v", d.name, s, err)
+			}
+			checkRoundTrip(t, d, "'"+esc+"'", '\'', d.backslashEscapes, s)
+			if !d.backslashEscapes {
+				selectLiteral(t, db, d, esc, s)
+			}
+		}
+	})
+}
+
+func FuzzEscapeIdentifier(f *testing.F) {
+	addSeeds(f)
+	db := openSQLite(f)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, d := range dialects {
+			esc, err := d.EscapeIdentifier(s)
+			if checkErr := d.Check(s); checkErr != nil {
+				if err == nil {
+					t.Fatalf("%s: accepted %q, which fails Check: %v", d.name, s, checkErr)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s: rejected %q: %v", d.name, s, err)
+			}
+			// Identifiers never treat backslash as an escape.
+			checkRoundTrip(t, d, d.identQuote+esc+d.identQuote, d.identQuote[0], false, s)
+			// SQLite rejects the empty identifier.
+			if d.identQuote == `"` && s != "" {
+				selectIdentifier(t, db, d, esc, s)
+			}
+		}
+	})
+}
+
+func FuzzAccount(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s, "%")
+		f.Add("app", s)
+	}
+	f.Add(generatedPassword(f), "%")
+
+	f.Fuzz(func(t *testing.T, user, host string) {
+		account, err := MySQL.Account(user, host)
+		if MySQL.Check(user) != nil || MySQL.Check(host) != nil {
+			if err == nil {
+				t.Fatalf("accepted %q@%q, which fails Check", user, host)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("rejected %q@%q: %v", user, host, err)
+		}
+		got, n, ok := scanQuoted(account, '\'', true)
+		if !ok || got != user || !strings.HasPrefix(account[n:], "@") {
+			t.Fatalf("%q does not start with the user %q and @", account, user)
+		}
+		checkRoundTrip(t, MySQL, account[n+1:], '\'', true, host)
+	})
+}
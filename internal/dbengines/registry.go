@@ -3,11 +3,15 @@ package dbengines
 import (
 	"DatabasePluginVault/internal/dbengines/Engine"
 	"DatabasePluginVault/internal/dbengines/mysql"
+	"DatabasePluginVault/internal/dbengines/postgres"
+	"DatabasePluginVault/internal/dbengines/sqlite"
 	"fmt"
 )
 
 var registry = map[string]func(map[string]interface{}) (Engine.Engine, error){
-	"mysql": mysql.NewEngine,
+	"mysql":      mysql.NewEngine,
+	"postgresql": postgres.NewEngine,
+	"sqlite":     sqlite.NewEngine,
 	// add other DBs here later...
 }
 
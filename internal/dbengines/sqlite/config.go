@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Config contains the full decoded, validated config for a SQLite database.
+// The engine is meant for local development and tests: it manages users in
+// its own table rather than real database accounts.
+type Config struct {
+	// ConnectionURL is the database file path or a file: URI.
+	ConnectionURL string `mapstructure:"connection_url"`
+
+	MaxOpenConnections       int         `mapstructure:"max_open_connections"`
+	MaxIdleConnections       int         `mapstructure:"max_idle_connections"`
+	MaxConnectionLifetimeRaw interface{} `mapstructure:"max_connection_lifetime"`
+	MaxConnectionLifetime    time.Duration
+
+	// Preserve original config input
+	RawConfig map[string]interface{}
+}
+
+// Load creates and validates a Config from raw config input (Vault passes this as map[string]interface{}).
+func Load(raw map[string]interface{}) (*Config, error) {
+	var cfg Config
+	cfg.RawConfig = raw
+
+	if err := mapstructure.WeakDecode(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode sqlite config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	// SQLite allows one writer at a time, so a single connection avoids
+	// SQLITE_BUSY errors between our own statements.
+	if cfg.MaxOpenConnections <= 0 {
+		cfg.MaxOpenConnections = 1
+	}
+	if cfg.MaxIdleConnections < 0 || cfg.MaxIdleConnections > cfg.MaxOpenConnections {
+		cfg.MaxIdleConnections = cfg.MaxOpenConnections
+	}
+
+	if cfg.MaxConnectionLifetimeRaw == nil {
+		cfg.MaxConnectionLifetimeRaw = "0s"
+	}
+	dur, err := parseutil.ParseDurationSecond(cfg.MaxConnectionLifetimeRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_connection_lifetime: %w", err)
+	}
+	cfg.MaxConnectionLifetime = dur
+
+	return &cfg, nil
+}
+
+// Validate checks the fields Load cannot default.
+func (c *Config) Validate() error {
+	if c.ConnectionURL == "" {
+		return fmt.Errorf("connection_url is required")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	// register the pure Go sqlite driver as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteDriver manages the *sql.DB pool.
+type SQLiteDriver struct {
+	cfg *Config
+	mu  sync.Mutex
+	db  *sql.DB
+}
+
+// NewConnectionProducer builds a driver from a typed Config.
+func NewConnectionProducer(cfg *Config) (*SQLiteDriver, error) {
+	return &SQLiteDriver{cfg: cfg}, nil
+}
+
+// Connect returns a cached *sql.DB or opens a new one, creating the users
+// table on first use.
+func (d *SQLiteDriver) Connect(ctx context.Context) (*sql.DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db != nil {
+		if err := d.db.PingContext(ctx); err == nil {
+			return d.db, nil
+		}
+		d.db.Close()
+		d.db = nil
+	}
+	db, err := sql.Open("sqlite", d.cfg.ConnectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite open: %w", err)
+	}
+	db.SetMaxOpenConns(d.cfg.MaxOpenConnections)
+	db.SetMaxIdleConns(d.cfg.MaxIdleConnections)
+	db.SetConnMaxLifetime(d.cfg.MaxConnectionLifetime)
+	if _, err := db.ExecContext(ctx, usersTableSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite create %s table: %w", usersTable, err)
+	}
+	d.db = db
+	return db, nil
+}
+
+// Close tears down the DB pool.
+func (d *SQLiteDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db != nil {
+		d.db.Close()
+		d.db = nil
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	engine "DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/internal/dbengines/statements"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+// maxUsernameLength caps usernames; SQLite itself has no limit.
+const maxUsernameLength = 255
+
+// version is the version of this engine reported in its metadata.
+const version = "1.0.0"
+
+// Engine implements dbengines.Engine for SQLite. Users are rows in the
+// vault_users table, so every call has an effect that can be inspected
+// with the sqlite3 shell.
+type Engine struct {
+	driver *SQLiteDriver
+}
+
+// NewEngine is used by the registry.
+func NewEngine(raw map[string]interface{}) (engine.Engine, error) {
+	cfg, err := Load(raw)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := NewConnectionProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{driver: driver}, nil
+}
+
+func (e *Engine) Connect(ctx context.Context) (*sql.DB, error) {
+	return e.driver.Connect(ctx)
+}
+
+func (e *Engine) Close() error {
+	return e.driver.Close()
+}
+
+func (e *Engine) MaxUsernameLength() int {
+	return maxUsernameLength
+}
+
+func (e *Engine) Metadata() engine.Metadata {
+	return engine.Metadata{
+		Name:    "sqlite",
+		Version: version,
+		Features: []engine.Feature{
+			engine.FeatureTransactions,
+			engine.FeatureRSAPrivateKey,
+			engine.FeatureClientCertificate,
+			engine.FeatureCustomStatements,
+		},
+	}
+}
+
+func (e *Engine) NewUser(ctx context.Context, req engine.NewUserRequest) error {
+	db, err := e.driver.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	v := statementValues{
+		name:       req.Username,
+		expiration: req.Expiration,
+	}
+	stmts := req.Statements
+	switch req.CredentialType {
+	case dbplugin.CredentialTypePassword:
+		v.password = req.Password
+		if len(stmts) == 0 {
+			stmts = []string{defaultCreationStatement}
+		}
+	case dbplugin.CredentialTypeRSAPrivateKey:
+		v.publicKey = string(req.PublicKey)
+		if len(stmts) == 0 {
+			stmts = []string{defaultRSACreationStatement}
+		}
+	case dbplugin.CredentialTypeClientCertificate:
+		v.subject, v.issuer = req.Subject, req.Issuer
+		if len(stmts) == 0 {
+			stmts = []string{defaultCertCreation}
+		}
+	default:
+		return fmt.Errorf("sqlite does not support %s credentials", req.CredentialType)
+	}
+	return statements.Exec(ctx, db, stmts, v.render)
+}
+
+func (e *Engine) UpdateUser(ctx context.Context, req engine.UpdateUserRequest) error {
+	db, err := e.driver.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	stmts := req.Statements
+	switch {
+	case len(stmts) > 0:
+	case req.Password != "":
+		stmts = []string{defaultRotationStatement}
+	default:
+		stmts = []string{defaultRenewStatement}
+	}
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name:       req.Username,
+		password:   req.Password,
+		expiration: req.Expiration,
+	}.render)
+}
+
+func (e *Engine) DeleteUser(ctx context.Context, req engine.DeleteUserRequest) error {
+	db, err := e.driver.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	stmts := req.Statements
+	if len(stmts) == 0 {
+		stmts = []string{defaultRevocationStatement}
+	}
+	return statements.Exec(ctx, db, stmts, statementValues{
+		name: req.Username,
+	}.render)
+}
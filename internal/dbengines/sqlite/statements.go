@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"DatabasePluginVault/internal/dbengines/quote"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// usersTable stands in for the database's accounts.
+const usersTable = "vault_users"
+
+const usersTableSchema = `CREATE TABLE IF NOT EXISTS ` + usersTable + ` (
+	username        TEXT PRIMARY KEY,
+	credential_type TEXT NOT NULL,
+	password        TEXT NOT NULL DEFAULT '',
+	public_key      TEXT NOT NULL DEFAULT '',
+	subject         TEXT NOT NULL DEFAULT '',
+	issuer          TEXT NOT NULL DEFAULT '',
+	expiration      TEXT NOT NULL DEFAULT '',
+	created_at      TEXT NOT NULL DEFAULT (datetime('now')),
+	updated_at      TEXT NOT NULL DEFAULT (datetime('now'))
+)`
+
+const (
+	defaultCreationStatement    = `INSERT INTO vault_users (username, credential_type, password, expiration) VALUES ('{{name}}', 'password', '{{password}}', '{{expiration}}');`
+	defaultRSACreationStatement = `INSERT INTO vault_users (username, credential_type, public_key, expiration) VALUES ('{{name}}', 'rsa_private_key', '{{public_key}}', '{{expiration}}');`
+	defaultCertCreation         = `INSERT INTO vault_users (username, credential_type, subject, issuer, expiration) VALUES ('{{name}}', 'client_certificate', '{{subject}}', '{{issuer}}', '{{expiration}}');`
+	// Rotation upserts so static roles work against a fresh database file
+	// without seeding the user first.
+	defaultRotationStatement = `INSERT INTO vault_users (username, credential_type, password) VALUES ('{{name}}', 'password', '{{password}}')
+ON CONFLICT (username) DO UPDATE SET password = excluded.password, updated_at = datetime('now');`
+	defaultRenewStatement      = `UPDATE vault_users SET expiration = '{{expiration}}', updated_at = datetime('now') WHERE username = '{{name}}';`
+	defaultRevocationStatement = `DELETE FROM vault_users WHERE username = '{{name}}';`
+
+	// expirationFormat matches SQLite's datetime() so expirations compare
+	// with datetime('now').
+	expirationFormat = "2006-01-02 15:04:05"
+)
+
+// statementValues holds the values substituted into statement templates.
+type statementValues struct {
+	name       string
+	password   string
+	expiration time.Time
+	publicKey  string
+	subject    string
+	issuer     string
+}
+
+// render substitutes the template variables in stmt. Values are escaped so
+// they are safe inside the single-quoted literals templates put them in;
+// values with control characters are rejected.
+func (v statementValues) render(stmt string) (string, error) {
+	pairs := make([]string, 0, 14)
+	for _, p := range []struct{ key, value string }{
+		{"{{name}}", v.name},
+		{"{{username}}", v.name},
+		{"{{password}}", v.password},
+		{"{{subject}}", v.subject},
+		{"{{issuer}}", v.issuer},
+	} {
+		esc, err := quote.SQLite.EscapeLiteral(p.value)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", p.key, err)
+		}
+		pairs = append(pairs, p.key, esc)
+	}
+	// PEM public keys span lines, so they are checked line by line.
+	var lines []string
+	for _, line := range strings.Split(v.publicKey, "\n") {
+		esc, err := quote.SQLite.EscapeLiteral(line)
+		if err != nil {
+			return "", fmt.Errorf("invalid {{public_key}}: %w", err)
+		}
+		lines = append(lines, esc)
+	}
+	var expiration string
+	if !v.expiration.IsZero() {
+		expiration = v.expiration.UTC().Format(expirationFormat)
+	}
+	pairs = append(pairs,
+		"{{public_key}}", strings.Join(lines, "\n"),
+		"{{expiration}}", expiration,
+	)
+	return strings.NewReplacer(pairs...).Replace(stmt), nil
+}
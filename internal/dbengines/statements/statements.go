@@ -0,0 +1,50 @@
+// Package statements runs the templated SQL statements the engines use to
+// create, rotate and drop users.
+package statements
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+)
+
+// Renderer substitutes the template variables in a single statement.
+type Renderer func(stmt string) (string, error)
+
+// Exec splits every template on ';', renders each statement and runs the
+// result in one transaction, so a failing statement leaves no partial change
+// behind. Databases that commit DDL implicitly, such as MySQL, only keep the
+// DML in the templates together.
+func Exec(ctx context.Context, db *sql.DB, templates []string, render Renderer) error {
+	// Render everything first so a rejected value fails before any
+	// statement runs.
+	var queries []string
+	for _, tmpl := range templates {
+		for _, query := range strutil.ParseArbitraryStringSlice(tmpl, ";") {
+			query = strings.TrimSpace(query)
+			if query == "" {
+				continue
+			}
+			rendered, err := render(query)
+			if err != nil {
+				return err
+			}
+			queries = append(queries, rendered)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
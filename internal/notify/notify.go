@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind identifies which credential a rotation event is about.
+type Kind string
+
+const (
+	KindRoot       Kind = "root"
+	KindStaticRole Kind = "static-role"
+)
+
+// Event describes the outcome of a single rotation.
+type Event struct {
+	Kind       Kind
+	Connection string
+	// Role is the static role as <db_type>/<name>; empty for root rotations.
+	Role     string
+	Username string
+	Time     time.Time
+	Err      error
+}
+
+// Success reports whether the rotation succeeded.
+func (e *Event) Success() bool {
+	return e.Err == nil
+}
+
+// Subject returns the mail subject line for the event.
+func (e *Event) Subject() string {
+	status := "succeeded"
+	if !e.Success() {
+		status = "FAILED"
+	}
+	target := e.Connection
+	if e.Kind == KindStaticRole {
+		target = e.Role
+	}
+	return fmt.Sprintf("[vault] %s rotation %s for %s", e.Kind, status, target)
+}
+
+// Body returns the plain-text mail body for the event.
+func (e *Event) Body() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Rotation type: %s\n", e.Kind)
+	fmt.Fprintf(&sb, "Connection:    %s\n", e.Connection)
+	if e.Role != "" {
+		fmt.Fprintf(&sb, "Role:          %s\n", e.Role)
+	}
+	fmt.Fprintf(&sb, "Username:      %s\n", e.Username)
+	fmt.Fprintf(&sb, "Time:          %s\n", e.Time.UTC().Format(time.RFC3339))
+	if e.Success() {
+		sb.WriteString("Result:        success\n")
+	} else {
+		fmt.Fprintf(&sb, "Result:        failure\nError:         %s\n", e.Err)
+	}
+	return sb.String()
+}
+
+// Notifier delivers rotation events to a list of recipients.
+type Notifier interface {
+	Notify(ctx context.Context, to []string, e *Event) error
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the mail server settings used for notifications.
+type SMTPConfig struct {
+	Host     string `json:"host" mapstructure:"host"`
+	Port     int    `json:"port" mapstructure:"port"`
+	Username string `json:"username" mapstructure:"username"`
+	Password string `json:"password" mapstructure:"password"`
+	From     string `json:"from" mapstructure:"from"`
+	// TLS dials the server with implicit TLS (e.g. port 465). Otherwise
+	// STARTTLS is used when the server offers it.
+	TLS bool `json:"tls" mapstructure:"tls"`
+	// Timeout bounds a single delivery, including the dial.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// Validate checks the settings needed to deliver mail.
+func (c *SMTPConfig) Validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if c.From == "" {
+		return fmt.Errorf("from is required")
+	}
+	return nil
+}
+
+// SMTPNotifier sends events as plain-text mail.
+type SMTPNotifier struct {
+	cfg *SMTPConfig
+}
+
+// NewSMTPNotifier builds a notifier from validated settings.
+func NewSMTPNotifier(cfg *SMTPConfig) (*SMTPNotifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &SMTPNotifier{cfg: cfg}, nil
+}
+
+// Notify sends e to every address in to.
+func (n *SMTPNotifier) Notify(ctx context.Context, to []string, e *Event) error {
+	if len(to) == 0 {
+		return nil
+	}
+	if n.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.cfg.Timeout)
+		defer cancel()
+	}
+
+	c, err := n.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	defer c.Close()
+
+	if !n.cfg.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+				return fmt.Errorf("smtp starttls: %w", err)
+			}
+		}
+	}
+	if n.cfg.Username != "" {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(n.cfg.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp rcpt %q: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(n.message(to, e)); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return c.Quit()
+}
+
+// dial opens the connection, honoring ctx for the dial and the deadline.
+func (n *SMTPNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if n.cfg.TLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: n.cfg.Host})
+	}
+
+	c, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// message renders the RFC 5322 message for e.
+func (n *SMTPNotifier) message(to []string, e *Event) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", e.Subject())
+	fmt.Fprintf(&sb, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(e.Body(), "\n", "\r\n"))
+	return []byte(sb.String())
+}
@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStub is a minimal in-process SMTP server that records one session.
+type smtpStub struct {
+	ln net.Listener
+	// rejectRcpt makes RCPT TO fail with a permanent error.
+	rejectRcpt bool
+
+	done     chan struct{}
+	commands []string
+	data     string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{ln: ln, done: make(chan struct{})}
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpStub) config() *SMTPConfig {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return &SMTPConfig{
+		Host:    "127.0.0.1",
+		Port:    addr.Port,
+		From:    "vault@example.com",
+		Timeout: 5 * time.Second,
+	}
+}
+
+// serve handles a single connection and then returns.
+func (s *smtpStub) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.commands = append(s.commands, line)
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO":
+			reply("250-stub")
+			reply("250 AUTH PLAIN")
+		case verb == "AUTH":
+			reply("235 ok")
+		case verb == "RCPT" && s.rejectRcpt:
+			reply("550 no such user")
+		case verb == "DATA":
+			reply("354 go ahead")
+			var sb strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				sb.WriteString(l)
+			}
+			s.data = sb.String()
+			reply("250 queued")
+		case verb == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func testEvent(err error) *Event {
+	return &Event{
+		Kind:       KindStaticRole,
+		Connection: "my-mysql",
+		Role:       "mysql/app",
+		Username:   "app",
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Err:        err,
+	}
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	stub := newSMTPStub(t)
+	go stub.serve()
+
+	cfg := stub.config()
+	cfg.Username = "vault"
+	cfg.Password = "secret"
+	n, err := NewSMTPNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := []string{"a@example.com", "b@example.com"}
+	if err := n.Notify(context.Background(), to, testEvent(errors.New("access denied"))); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	<-stub.done
+
+	want := []string{
+		"AUTH PLAIN",
+		"MAIL FROM:<vault@example.com>",
+		"RCPT TO:<a@example.com>",
+		"RCPT TO:<b@example.com>",
+		"DATA",
+		"QUIT",
+	}
+	cmds := strings.Join(stub.commands, "\n")
+	for _, w := range want {
+		if !strings.Contains(cmds, w) {
+			t.Errorf("missing command %q in session:\n%s", w, cmds)
+		}
+	}
+	for _, w := range []string{
+		"From: vault@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: [vault] static-role rotation FAILED for mysql/app\r\n",
+		"Error:         access denied\r\n",
+	} {
+		if !strings.Contains(stub.data, w) {
+			t.Errorf("message is missing %q:\n%s", w, stub.data)
+		}
+	}
+}
+
+func TestSMTPNotifier_RejectedRecipient(t *testing.T) {
+	stub := newSMTPStub(t)
+	stub.rejectRcpt = true
+	go stub.serve()
+
+	n, err := NewSMTPNotifier(stub.config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Notify(context.Background(), []string{"nobody@example.com"}, testEvent(nil))
+	if err == nil || !strings.Contains(err.Error(), "smtp rcpt") {
+		t.Fatalf("expected rcpt error, got %v", err)
+	}
+}
+
+func TestSMTPNotifier_NoRecipients(t *testing.T) {
+	// Nothing listens on the port, so any dial would fail.
+	n, err := NewSMTPNotifier(&SMTPConfig{Host: "127.0.0.1", Port: 1, From: "vault@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), nil, testEvent(nil)); err != nil {
+		t.Fatalf("Notify with no recipients: %v", err)
+	}
+}
+
+func TestNewSMTPNotifier_Validate(t *testing.T) {
+	for _, cfg := range []*SMTPConfig{
+		{Port: 25, From: "vault@example.com"},
+		{Host: "mail", Port: 0, From: "vault@example.com"},
+		{Host: "mail", Port: 25},
+	} {
+		if _, err := NewSMTPNotifier(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	if _, err := NewSMTPNotifier(&SMTPConfig{Host: "mail", Port: 587, From: "vault@example.com"}); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
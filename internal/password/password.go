@@ -0,0 +1,105 @@
+package password
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// DefaultLength is the length of passwords from the built-in generator.
+	DefaultLength = 24
+
+	lower   = "abcdefghijklmnopqrstuvwxyz"
+	upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits  = "0123456789"
+	special = "-_.!#*+=^~"
+)
+
+// charsets are the classes MySQL's validate_password plugin requires at
+// least one character from. special avoids quotes, backslashes and the
+// characters that delimit a DSN.
+var charsets = []string{lower, upper, digits, special}
+
+// PolicyGenerator generates a password from a named Vault password policy.
+// logical.SystemView implements it.
+type PolicyGenerator interface {
+	GeneratePasswordFromPolicy(ctx context.Context, policyName string) (string, error)
+}
+
+// Policy returns the password policy to use: the role's if set, otherwise
+// the connection's.
+func Policy(rolePolicy, connPolicy string) string {
+	if rolePolicy != "" {
+		return rolePolicy
+	}
+	return connPolicy
+}
+
+// Generate returns a password from the named policy, or from the built-in
+// generator when policy is empty.
+func Generate(ctx context.Context, gen PolicyGenerator, policy string) (string, error) {
+	if policy == "" {
+		return Default(DefaultLength)
+	}
+	pw, err := gen.GeneratePasswordFromPolicy(ctx, policy)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password from policy %q: %w", policy, err)
+	}
+	return pw, nil
+}
+
+// Default returns a random password of the given length that satisfies
+// MySQL's validate_password MEDIUM policy: at least one lowercase letter,
+// uppercase letter, digit and special character.
+func Default(length int) (string, error) {
+	if length < len(charsets) {
+		return "", fmt.Errorf("password length must be at least %d", len(charsets))
+	}
+
+	var all string
+	for _, cs := range charsets {
+		all += cs
+	}
+
+	buf := make([]byte, length)
+	for i := range buf {
+		// the first characters cover each class once
+		set := all
+		if i < len(charsets) {
+			set = charsets[i]
+		}
+		c, err := randomChar(set)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = c
+	}
+
+	// Fisher-Yates so the guaranteed characters are not always up front.
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf), nil
+}
+
+func randomChar(set string) (byte, error) {
+	i, err := randomInt(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[i], nil
+}
+
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
@@ -0,0 +1,59 @@
+package username
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/helper/template"
+)
+
+// DefaultTemplate renders names like v_<role>_<displayname>_<random>_<unix>
+// and truncates them to MySQL's 32-character limit.
+const DefaultTemplate = `{{ printf "v_%s_%s_%s_%s" (.RoleName | truncate 5) (.DisplayName | truncate 5) (random 6) (unix_time) | truncate 32 }}`
+
+// Metadata is the data a username template is rendered with.
+type Metadata struct {
+	RoleName    string
+	DisplayName string
+}
+
+// Template returns the template to use: the role's if set, otherwise the
+// connection's, otherwise DefaultTemplate.
+func Template(roleTmpl, connTmpl string) string {
+	switch {
+	case roleTmpl != "":
+		return roleTmpl
+	case connTmpl != "":
+		return connTmpl
+	default:
+		return DefaultTemplate
+	}
+}
+
+// Render renders tmpl and rejects results longer than maxLen, the engine's
+// username limit. maxLen <= 0 means unlimited.
+func Render(tmpl string, maxLen int, md Metadata) (string, error) {
+	t, err := template.NewTemplate(template.Template(tmpl))
+	if err != nil {
+		return "", fmt.Errorf("invalid username_template: %w", err)
+	}
+	name, err := t.Generate(md)
+	if err != nil {
+		return "", fmt.Errorf("failed to render username_template: %w", err)
+	}
+	if name == "" {
+		return "", fmt.Errorf("username_template rendered an empty username")
+	}
+	if maxLen > 0 && len(name) > maxLen {
+		return "", fmt.Errorf("username %q is %d characters, longer than the engine limit of %d; use truncate in username_template", name, len(name), maxLen)
+	}
+	return name, nil
+}
+
+// Validate checks that tmpl parses and that a sample rendering fits maxLen.
+func Validate(tmpl string, maxLen int) error {
+	_, err := Render(tmpl, maxLen, Metadata{
+		RoleName:    "role-name-sample",
+		DisplayName: "token-display-name-sample",
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package dbsecretengine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+)
+
+// listPageFields are the after/limit fields shared by the paginated LIST
+// paths.
+func listPageFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"after": {
+			Type:        framework.TypeString,
+			Description: "Only return keys that sort after this one.",
+		},
+		"limit": {
+			Type:        framework.TypeInt,
+			Description: "Maximum number of keys to return. Zero returns all remaining keys.",
+		},
+	}
+}
+
+// paginateKeys sorts keys and returns at most limit of those after the
+// given key. A limit of zero returns every remaining key.
+func paginateKeys(keys []string, after string, limit int) ([]string, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+	sort.Strings(keys)
+	if after != "" {
+		i := sort.Search(len(keys), func(i int) bool { return strings.Compare(keys[i], after) > 0 })
+		keys = keys[i:]
+	}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
+
+// listPage applies the request's after/limit fields to keys.
+func listPage(d *framework.FieldData, keys []string) ([]string, error) {
+	return paginateKeys(keys, d.Get("after").(string), d.Get("limit").(int))
+}
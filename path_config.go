@@ -2,6 +2,9 @@ package dbsecretengine
 
 import (
 	"DatabasePluginVault/internal/dbengines"
+	"DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/internal/dbengines/dsn"
+	usertmpl "DatabasePluginVault/internal/username"
 	"DatabasePluginVault/storage"
 	"context"
 	"fmt"
@@ -27,7 +30,7 @@ func pathConfigurePluginConnection(b *databaseBackend) []*framework.Path {
 				},
 				"plugin_name": {
 					Type:        framework.TypeString,
-					Description: "Name of the plugin to use.",
+					Description: "Name of the plugin to use: mysql, postgresql or sqlite.",
 				},
 				"plugin_version": {
 					Type:        framework.TypeString,
@@ -40,11 +43,11 @@ func pathConfigurePluginConnection(b *databaseBackend) []*framework.Path {
 				},
 				"allowed_roles": {
 					Type:        framework.TypeCommaStringSlice,
-					Description: "Comma-separated or array of allowed role names. '*' allows all.",
+					Description: "Comma-separated or array of role names allowed to use this connection. Globs such as 'app-*' are supported and '*' allows all.",
 				},
 				"root_rotation_statements": {
 					Type:        framework.TypeCommaStringSlice,
-					Description: "Statements to execute for rotating root credentials.",
+					Description: "Statements to execute for rotating root credentials, templated with {{name}} and {{password}}.",
 				},
 				"password_policy": {
 					Type:        framework.TypeString,
@@ -58,6 +61,10 @@ func pathConfigurePluginConnection(b *databaseBackend) []*framework.Path {
 					Type:        framework.TypeCommaStringSlice,
 					Description: "Emails for rotation result notifications.",
 				},
+				"username_template": {
+					Type:        framework.TypeString,
+					Description: "Template for dynamic usernames, rendered with .RoleName and .DisplayName. Roles may override it.",
+				},
 			},
 			ExistenceCheck: b.connectionExistenceCheck(),
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -75,6 +82,40 @@ func pathConfigurePluginConnection(b *databaseBackend) []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "config/?$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "database",
+			},
+			Fields: listPageFields(),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.connectionListHandler(),
+				},
+			},
+			HelpSynopsis:    "List configured database connections.",
+			HelpDescription: "Returns connection names with their connection_name, plugin_name and ci_name in key_info. Use after and limit to page through large mounts.",
+		},
+		{
+			Pattern: "reset/" + framework.GenericNameRegex("name"),
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "database",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of this database connection",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.connectionResetHandler(),
+				},
+			},
+			HelpSynopsis:    "Re-establish a connection's pool.",
+			HelpDescription: "Builds a fresh engine from the stored config, verifies it and swaps it in. The old pool is closed once the operations running on it finish.",
+		},
 	}
 }
 
@@ -86,6 +127,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		}
 		verifyConn := data.Get("verify_connection").(bool)
 
+		defer b.lockConnection(name)()
 		// Read existing config
 		config := &storage.DatabaseConfig{}
 		entry, err := req.Storage.Get(ctx, fmt.Sprintf("config/%s", name))
@@ -116,10 +158,18 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		}
 		if pwdPolicyRaw, ok := data.GetOk("password_policy"); ok {
 			config.PasswordPolicy = pwdPolicyRaw.(string)
+			if config.PasswordPolicy != "" {
+				if _, err := b.generatePassword(ctx, config.PasswordPolicy); err != nil {
+					return logical.ErrorResponse(fmt.Sprintf("invalid password_policy: %s", err)), nil
+				}
+			}
 		}
 		if ciName, ok := data.GetOk("ci_name"); ok {
 			config.CiName = ciName.(string)
 		}
+		if tmplRaw, ok := data.GetOk("username_template"); ok {
+			config.UsernameTemplate = tmplRaw.(string)
+		}
 
 		// Sanitize framework data to store only custom DB fields
 		delete(data.Raw, "name")
@@ -129,6 +179,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		delete(data.Raw, "root_rotation_statements")
 		delete(data.Raw, "password_policy")
 		delete(data.Raw, "verify_connection")
+		delete(data.Raw, "username_template")
 
 		// Store remaining fields as ConnectionDetails
 		if config.ConnectionDetails == nil {
@@ -143,16 +194,30 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		if err != nil {
 			return logical.ErrorResponse(fmt.Sprintf("invalid plugin config: %s", err)), nil
 		}
+		if len(config.RootCredentialsRotateStatements) > 0 {
+			if err := engine.Metadata().Require(Engine.FeatureCustomStatements); err != nil {
+				engine.Close()
+				return logical.ErrorResponse(err.Error()), nil
+			}
+		}
+		if config.UsernameTemplate != "" {
+			if err := usertmpl.Validate(config.UsernameTemplate, engine.MaxUsernameLength()); err != nil {
+				engine.Close()
+				return logical.ErrorResponse(err.Error()), nil
+			}
+		}
 		log.Print("About to verify")
 		// Test DB connection
 		if verifyConn {
 			if _, err := engine.Connect(ctx); err != nil {
+				engine.Close()
 				return logical.ErrorResponse(fmt.Sprintf("connection failed: %s", err)), nil
 			}
 		}
 
 		// Save config
 		if err := b.storeConfig(ctx, req.Storage, name, config); err != nil {
+			engine.Close()
 			return nil, err
 		}
 
@@ -170,7 +235,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		resp := &logical.Response{}
 		if parsed, ok := config.ConnectionDetails["connection_url"].(string); ok {
 			if u, err := url.Parse(parsed); err == nil && u.User != nil {
-				if _, ok := u.User.Password(); ok {
+				if pw, ok := u.User.Password(); ok && pw != dsn.PasswordTemplate {
 					resp.AddWarning("Password found in connection_url, use a templated URL to avoid password leak.")
 				}
 			}
@@ -197,8 +262,9 @@ func (b *databaseBackend) connectionReadHandler() framework.OperationFunc {
 		// redact password fields if any
 		delete(cfg.ConnectionDetails, "password")
 		delete(cfg.ConnectionDetails, "private_key")
+		delete(cfg.ConnectionDetails, "tls_client_key")
 
-		return &logical.Response{
+		resp := &logical.Response{
 			Data: map[string]interface{}{
 				"plugin_name":              cfg.PluginName,
 				"plugin_version":           cfg.PluginVersion,
@@ -207,9 +273,21 @@ func (b *databaseBackend) connectionReadHandler() framework.OperationFunc {
 				"ci_name":                  cfg.CiName,
 				"password_policy":          cfg.PasswordPolicy,
 				"root_rotation_statements": cfg.RootCredentialsRotateStatements,
+				"username_template":        cfg.UsernameTemplate,
 				"connection_details":       cfg.ConnectionDetails,
 			},
-		}, nil
+		}
+		if eng, err := b.getEngine(ctx, req.Storage, name); err == nil {
+			md := eng.Metadata()
+			resp.Data["capabilities"] = map[string]interface{}{
+				"engine":   md.Name,
+				"version":  md.Version,
+				"features": md.Features,
+			}
+		} else {
+			resp.AddWarning(fmt.Sprintf("failed to load engine capabilities: %v", err))
+		}
+		return resp, nil
 	}
 }
 
@@ -224,6 +302,69 @@ func (b *databaseBackend) connectionDeleteHandler() framework.OperationFunc {
 	}
 }
 
+func (b *databaseBackend) connectionListHandler() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		keys, err := req.Storage.List(ctx, configPathPrefix)
+		if err != nil {
+			return nil, err
+		}
+		keys, err = listPage(data, keys)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		keyInfo := make(map[string]interface{}, len(keys))
+		for _, name := range keys {
+			cfg, err := storage.LoadConnection(ctx, req.Storage, name)
+			if err != nil {
+				return nil, err
+			}
+			if cfg == nil {
+				continue
+			}
+			keyInfo[name] = map[string]interface{}{
+				"connection_name": name,
+				"plugin_name":     cfg.PluginName,
+				"ci_name":         cfg.CiName,
+			}
+		}
+		return logical.ListResponseWithInfo(keys, keyInfo), nil
+	}
+}
+
+func (b *databaseBackend) connectionResetHandler() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		config, err := storage.LoadConnection(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			return logical.ErrorResponse("unknown connection: %s", name), nil
+		}
+
+		engine, err := dbengines.New(config.PluginName, config.ConnectionDetails)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid plugin config: %s", err)), nil
+		}
+		if _, err := engine.Connect(ctx); err != nil {
+			engine.Close()
+			return logical.ErrorResponse(fmt.Sprintf("connection failed: %s", err)), nil
+		}
+
+		// Requests from here on use the new engine; the old one is closed
+		// in the background once its in-flight operations return.
+		if old := b.conn.Put(name, engine); old != nil {
+			go func() {
+				if err := old.Close(); err != nil {
+					b.logger.Warn("failed to close replaced connection", "connection", name, "error", err)
+				}
+			}()
+		}
+		return nil, nil
+	}
+}
+
 func (b *databaseBackend) connectionExistenceCheck() framework.ExistenceFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
 		name := data.Get("name").(string)
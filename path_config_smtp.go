@@ -0,0 +1,153 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/notify"
+	"DatabasePluginVault/storage"
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// PathConfigSMTP returns the plugin-level path for the mail server used to
+// send rotation notifications to each connection's emails.
+func PathConfigSMTP(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "smtp/config",
+		Fields: map[string]*framework.FieldSchema{
+			"host": {
+				Type:        framework.TypeString,
+				Description: "SMTP server host.",
+				Required:    true,
+			},
+			"port": {
+				Type:        framework.TypeInt,
+				Default:     25,
+				Description: "SMTP server port.",
+			},
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username for SMTP authentication. Leave empty to send without authentication.",
+			},
+			"password": {
+				Type:        framework.TypeString,
+				Description: "Password for SMTP authentication.",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+			"from": {
+				Type:        framework.TypeString,
+				Description: "Sender address of notification mails.",
+				Required:    true,
+			},
+			"tls": {
+				Type:        framework.TypeBool,
+				Description: "Connect with implicit TLS instead of STARTTLS.",
+			},
+			"timeout": {
+				Type:        framework.TypeDurationSecond,
+				Default:     30,
+				Description: "Timeout for delivering a single notification.",
+			},
+		},
+		ExistenceCheck: b.smtpConfigExistenceCheck,
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.handleSMTPConfigWrite,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleSMTPConfigWrite,
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleSMTPConfigRead,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.handleSMTPConfigDelete,
+			},
+		},
+		HelpSynopsis:    "Configure the mail server for rotation notifications.",
+		HelpDescription: "Root and static role rotations send a success or failure notice to the emails of their connection through this server. Deleting the config disables notifications.",
+	}
+}
+
+func (b *databaseBackend) handleSMTPConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	c, err := storage.LoadSMTPConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = &notify.SMTPConfig{
+			Port:    d.Get("port").(int),
+			Timeout: time.Duration(d.Get("timeout").(int)) * time.Second,
+		}
+	}
+
+	if v, ok := d.GetOk("host"); ok {
+		c.Host = v.(string)
+	}
+	if v, ok := d.GetOk("port"); ok {
+		c.Port = v.(int)
+	}
+	if v, ok := d.GetOk("username"); ok {
+		c.Username = v.(string)
+	}
+	if v, ok := d.GetOk("password"); ok {
+		c.Password = v.(string)
+	}
+	if v, ok := d.GetOk("from"); ok {
+		c.From = v.(string)
+	}
+	if v, ok := d.GetOk("tls"); ok {
+		c.TLS = v.(bool)
+	}
+	if v, ok := d.GetOk("timeout"); ok {
+		c.Timeout = time.Duration(v.(int)) * time.Second
+	}
+
+	if err := c.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err := storage.SaveSMTPConfig(ctx, req.Storage, c); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *databaseBackend) handleSMTPConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	c, err := storage.LoadSMTPConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+
+	// password is write-only
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"host":     c.Host,
+			"port":     c.Port,
+			"username": c.Username,
+			"from":     c.From,
+			"tls":      c.TLS,
+			"timeout":  c.Timeout.Seconds(),
+		},
+	}, nil
+}
+
+func (b *databaseBackend) handleSMTPConfigDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := storage.DeleteSMTPConfig(ctx, req.Storage); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *databaseBackend) smtpConfigExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	c, err := storage.LoadSMTPConfig(ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+	return c != nil, nil
+}
@@ -0,0 +1,137 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/credential"
+	"DatabasePluginVault/internal/dbengines/Engine"
+	usertmpl "DatabasePluginVault/internal/username"
+	"DatabasePluginVault/storage"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// PathCreds returns the path that issues short-lived users for a dynamic role.
+func PathCreds(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback:                    b.handleCredsRead,
+				ForwardPerformanceSecondary: true,
+				ForwardPerformanceStandby:   true,
+			},
+		},
+		HelpSynopsis:    "Request database credentials for a dynamic role.",
+		HelpDescription: "Creates a database user for the role and returns it under a lease. The user is dropped when the lease is revoked or expires.",
+	}
+}
+
+func (b *databaseBackend) handleCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	roleObj, err := storage.LoadRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		return logical.ErrorResponse("unknown role: %s", name), nil
+	}
+	conf, err := storage.LoadConnection(ctx, req.Storage, roleObj.DBName)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return logical.ErrorResponse("connection %q does not exist", roleObj.DBName), nil
+	}
+	if err := conf.CheckRoleAllowed(roleObj.DBName, name); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	eng, err := b.getEngine(ctx, req.Storage, roleObj.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := usertmpl.Render(
+		usertmpl.Template(roleObj.UsernameTemplate, conf.UsernameTemplate),
+		eng.MaxUsernameLength(),
+		usertmpl.Metadata{RoleName: name, DisplayName: req.DisplayName},
+	)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	ttl := roleObj.DefaultTTL
+	if ttl == 0 {
+		ttl = b.System().DefaultLeaseTTL()
+	}
+	newUser := Engine.NewUserRequest{
+		Username:       username,
+		Expiration:     time.Now().Add(ttl),
+		Statements:     roleObj.Statements.Commands,
+		CredentialType: roleObj.CredentialType,
+	}
+	data := map[string]interface{}{
+		"username": username,
+	}
+
+	switch roleObj.CredentialType {
+	case dbplugin.CredentialTypePassword:
+		password, err := b.generatePassword(ctx, conf.PasswordPolicy)
+		if err != nil {
+			return nil, err
+		}
+		newUser.Password = password
+		data["password"] = password
+	case dbplugin.CredentialTypeRSAPrivateKey:
+		cfg, err := credential.ParseRSAConfig(roleObj.CredentialConfig)
+		if err != nil {
+			return nil, err
+		}
+		kp, err := credential.GenerateRSAKey(cfg.KeyBits)
+		if err != nil {
+			return nil, err
+		}
+		newUser.PublicKey = kp.PublicKey
+		data["rsa_private_key"] = string(kp.PrivateKey)
+	case dbplugin.CredentialTypeClientCertificate:
+		cfg, err := credential.ParseCertConfig(roleObj.CredentialConfig)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := cfg.IssueClientCert(username)
+		if err != nil {
+			return nil, err
+		}
+		newUser.Subject = cert.Subject
+		newUser.Issuer = cert.Issuer
+		data["client_certificate"] = string(cert.Certificate)
+		data["private_key"] = string(cert.PrivateKey)
+		data["private_key_type"] = "rsa"
+		data["issuing_ca"] = string(cert.IssuingCA)
+	default:
+		return logical.ErrorResponse("unsupported credential_type %q", roleObj.CredentialType), nil
+	}
+
+	if err := eng.NewUser(ctx, newUser); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to create user: %v", err)), nil
+	}
+
+	resp := b.Secret(SecretCredsType).Response(data, map[string]interface{}{
+		"username": username,
+		"role":     name,
+		"db_name":  roleObj.DBName,
+	})
+	resp.Secret.TTL = roleObj.DefaultTTL
+	resp.Secret.MaxTTL = roleObj.MaxTTL
+	return resp, nil
+}
@@ -0,0 +1,230 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/credential"
+	"DatabasePluginVault/internal/dbengines/Engine"
+	usertmpl "DatabasePluginVault/internal/username"
+	"DatabasePluginVault/role"
+	"DatabasePluginVault/storage"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// PathRoles returns the path definitions for dynamic roles.
+func PathRoles(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "roles/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+					Required:    true,
+				},
+				"db_name": {
+					Type:        framework.TypeString,
+					Description: "Name of the database connection to create users on.",
+					Required:    true,
+				},
+				"creation_statements": {
+					Type:        framework.TypeStringSlice,
+					Description: "SQL statements to create the user, templated with {{name}}, {{password}} and {{expiration}}, plus {{account}} on MySQL. Defaults to the engine's CREATE USER.",
+				},
+				"credential_type": {
+					Type:        framework.TypeString,
+					Default:     "password",
+					Description: "Type of credential to issue: password, rsa_private_key or client_certificate.",
+				},
+				"credential_config": {
+					Type:        framework.TypeMap,
+					Description: "Options for the credential type. rsa_private_key takes key_bits; client_certificate takes ca_cert, ca_private_key, key_bits and ttl.",
+				},
+				"username_template": {
+					Type:        framework.TypeString,
+					Description: "Template for usernames issued by this role, rendered with .RoleName and .DisplayName. Overrides the connection's username_template.",
+				},
+				"default_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Default ttl for credentials issued by this role.",
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Maximum ttl credentials issued by this role can be renewed to.",
+				},
+			},
+			ExistenceCheck: b.roleExistenceCheck,
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback:                    b.handleRoleWrite,
+					ForwardPerformanceSecondary: true,
+					ForwardPerformanceStandby:   true,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.handleRoleWrite,
+					ForwardPerformanceSecondary: true,
+					ForwardPerformanceStandby:   true,
+				},
+				logical.ReadOperation: &framework.PathOperation{Callback: b.handleRoleRead},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback:                    b.handleRoleDelete,
+					ForwardPerformanceSecondary: true,
+					ForwardPerformanceStandby:   true,
+				},
+			},
+			HelpSynopsis:    "Manage dynamic database roles.",
+			HelpDescription: "This path lets you configure roles that issue short-lived database users through creds/<name>.",
+		},
+		{
+			Pattern: "roles/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{Callback: b.handleRoleList},
+			},
+			HelpSynopsis:    "List dynamic roles.",
+			HelpDescription: "Returns a list of dynamic role names.",
+		},
+	}
+}
+
+func (b *databaseBackend) handleRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	roleObj, err := storage.LoadRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		roleObj = &role.RoleEntry{
+			CredentialType: dbplugin.CredentialTypePassword,
+		}
+	}
+
+	if v, ok := d.GetOk("db_name"); ok {
+		roleObj.DBName = v.(string)
+	}
+	if v, ok := d.GetOk("creation_statements"); ok {
+		roleObj.Statements = dbplugin.Statements{Commands: v.([]string)}
+	}
+	if v, ok := d.GetOk("credential_type"); ok {
+		credType, err := dbplugin.CredentialTypeString(v.(string))
+		if err != nil {
+			return logical.ErrorResponse("invalid credential_type %q", v), nil
+		}
+		roleObj.CredentialType = credType
+	}
+	if v, ok := d.GetOk("credential_config"); ok {
+		roleObj.CredentialConfig = v.(map[string]interface{})
+	}
+	if v, ok := d.GetOk("username_template"); ok {
+		roleObj.UsernameTemplate = v.(string)
+	}
+	if v, ok := d.GetOk("default_ttl"); ok {
+		roleObj.DefaultTTL = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := d.GetOk("max_ttl"); ok {
+		roleObj.MaxTTL = time.Duration(v.(int)) * time.Second
+	}
+
+	if err := roleObj.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err := credential.Validate(roleObj.CredentialType, roleObj.CredentialConfig); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	conf, err := storage.LoadConnection(ctx, req.Storage, roleObj.DBName)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return logical.ErrorResponse("connection %q does not exist", roleObj.DBName), nil
+	}
+	if err := conf.CheckRoleAllowed(roleObj.DBName, name); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	eng, err := b.getEngine(ctx, req.Storage, roleObj.DBName)
+	if err != nil {
+		return nil, err
+	}
+	if err := eng.Metadata().Require(roleFeatures(roleObj)...); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if roleObj.UsernameTemplate != "" {
+		if err := usertmpl.Validate(roleObj.UsernameTemplate, eng.MaxUsernameLength()); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if err := storage.SaveRole(ctx, req.Storage, name, roleObj); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to save role: %v", err)), nil
+	}
+	return nil, nil
+}
+
+// roleFeatures returns the engine features a dynamic role's options need.
+func roleFeatures(r *role.RoleEntry) []Engine.Feature {
+	var features []Engine.Feature
+	if f, ok := Engine.CredentialFeature(r.CredentialType); ok {
+		features = append(features, f)
+	}
+	if len(r.Statements.Commands) > 0 {
+		features = append(features, Engine.FeatureCustomStatements)
+	}
+	return features
+}
+
+func (b *databaseBackend) handleRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleObj, err := storage.LoadRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		return nil, nil
+	}
+
+	// the CA key is write-only
+	credConfig := make(map[string]interface{}, len(roleObj.CredentialConfig))
+	for k, v := range roleObj.CredentialConfig {
+		if k != "ca_private_key" {
+			credConfig[k] = v
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"db_name":             roleObj.DBName,
+			"creation_statements": roleObj.Statements.Commands,
+			"default_ttl":         roleObj.DefaultTTL.Seconds(),
+			"max_ttl":             roleObj.MaxTTL.Seconds(),
+			"credential_type":     roleObj.CredentialType.String(),
+			"credential_config":   credConfig,
+			"username_template":   roleObj.UsernameTemplate,
+		},
+	}, nil
+}
+
+func (b *databaseBackend) handleRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := storage.DeleteRole(ctx, req.Storage, d.Get("name").(string)); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to delete role: %v", err)), nil
+	}
+	return nil, nil
+}
+
+func (b *databaseBackend) handleRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	keys, err := req.Storage.List(ctx, storage.RolePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(keys), nil
+}
+
+func (b *databaseBackend) roleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	r, err := storage.LoadRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return r != nil, nil
+}
@@ -0,0 +1,113 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/dbengines/dsn"
+	"DatabasePluginVault/role"
+	"DatabasePluginVault/storage"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// PathRotateRoot returns the path that rotates a connection's root
+// credential so that only Vault knows it afterwards.
+func PathRotateRoot(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "rotate-root/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of this database connection",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.handleRotateRoot,
+				ForwardPerformanceSecondary: true,
+				ForwardPerformanceStandby:   true,
+			},
+		},
+		HelpSynopsis:    "Rotate a connection's root credential.",
+		HelpDescription: "Generates a new password for the connection's username, applies it with root_rotation_statements (or the engine default) and stores it in the connection config.",
+	}
+}
+
+func (b *databaseBackend) handleRotateRoot(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	defer b.lockConnection(name)()
+	config, err := storage.LoadConnection(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("unknown connection: %s", name), nil
+	}
+	// Only the password field is rotated, so a password written into the
+	// URL would keep being used and Vault would lock itself out.
+	connURL, _ := config.ConnectionDetails["connection_url"].(string)
+	if !strings.Contains(connURL, dsn.PasswordTemplate) {
+		return logical.ErrorResponse("connection_url must contain %s to rotate the root credential", dsn.PasswordTemplate), nil
+	}
+	if err := b.rotateRootCredentials(ctx, req.Storage, name, config); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to rotate root credentials: %v", err)), nil
+	}
+	return nil, nil
+}
+
+// PathRotateRole returns the path that forces an immediate rotation of a
+// static role's credential.
+func PathRotateRole(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "rotate-role/" + framework.GenericNameRegex("db_type") + "/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+				Required:    true,
+			},
+			"db_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the database (e.g. mysql, snowflake).",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.handleRotateRole,
+				ForwardPerformanceSecondary: true,
+				ForwardPerformanceStandby:   true,
+			},
+		},
+		HelpSynopsis:    "Rotate a static role's credential now.",
+		HelpDescription: "Generates a new password for the static role, applies it to the database and restarts its rotation schedule.",
+	}
+}
+
+func (b *databaseBackend) handleRotateRole(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	st := storage.NewBackendStorage(req.Storage)
+
+	dbType := d.Get("db_type").(string)
+	name := d.Get("name").(string)
+
+	defer b.lockStaticRole(dbType, name)()
+	roleObj, err := role.GetStaticRole(ctx, st, dbType, name)
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		return logical.ErrorResponse("unknown static role: %s/%s", dbType, name), nil
+	}
+
+	if err := b.rotateStaticRole(ctx, req.Storage, roleObj); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to rotate credentials: %v", err)), nil
+	}
+	if err := b.scheduleStaticRole(roleObj); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
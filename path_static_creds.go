@@ -0,0 +1,81 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/role"
+	"DatabasePluginVault/storage"
+	"context"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// PathStaticCreds returns the read-only path apps use to fetch the current
+// credential of a static role. It is kept apart from static-roles/ so policies
+// can grant it without granting role management.
+func PathStaticCreds(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-creds/" + framework.GenericNameRegex("db_type") + "/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+				Required:    true,
+			},
+			"db_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the database (e.g. mysql, snowflake).",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{Callback: b.handleStaticCredsRead},
+		},
+		HelpSynopsis:    "Read the current credential of a static role.",
+		HelpDescription: "Returns the username and current password Vault manages for the static role, along with when it was last rotated and how long it remains valid.",
+	}
+}
+
+func (b *databaseBackend) handleStaticCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	st := storage.NewBackendStorage(req.Storage)
+
+	dbType := d.Get("db_type").(string)
+	name := d.Get("name").(string)
+
+	roleObj, err := role.GetStaticRole(ctx, st, dbType, name)
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		return logical.ErrorResponse("unknown static role: %s/%s", dbType, name), nil
+	}
+	conf, err := storage.LoadConnection(ctx, req.Storage, roleObj.ConnectionName)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return logical.ErrorResponse("connection %q does not exist", roleObj.ConnectionName), nil
+	}
+	if err := conf.CheckRoleAllowed(roleObj.ConnectionName, name); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// Without automatic rotation the credential does not expire.
+	var ttl float64
+	if roleObj.ShouldAutoRotate() {
+		ttl = roleObj.CredentialTTL().Seconds()
+		if ttl < 0 {
+			ttl = 0
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"username":            roleObj.Username,
+			"password":            roleObj.Password,
+			"last_vault_rotation": roleObj.LastVaultRotation,
+			"rotation_period":     roleObj.RotationPeriod.Seconds(),
+			"rotation_schedule":   roleObj.RotationSchedule,
+			"ttl":                 int64(ttl),
+		},
+	}, nil
+}
@@ -1,16 +1,20 @@
 package dbsecretengine
 
 import (
+	"DatabasePluginVault/internal/dbengines/Engine"
 	"DatabasePluginVault/role"
 	"DatabasePluginVault/storage"
 	"context"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
 // PathStaticRoles returns the Vault path definitions for static roles.
-func PathStaticRoles() *framework.Path {
+func PathStaticRoles(b *databaseBackend) *framework.Path {
 	return &framework.Path{
 		Pattern: "static-roles/" + framework.GenericNameRegex("db_type") + "/" + framework.GenericNameRegex("name"),
 		Fields: map[string]*framework.FieldSchema{
@@ -41,25 +45,56 @@ func PathStaticRoles() *framework.Path {
 			},
 			"rotation_statements": {
 				Type:        framework.TypeStringSlice,
-				Description: "SQL statements to use during password rotation.",
+				Description: "SQL statements to use during password rotation, templated with {{name}} and {{password}}.",
+				Required:    false,
+			},
+			"revoke_user_on_delete": {
+				Type:        framework.TypeBool,
+				Description: "Drop the database user when the static role is deleted.",
+				Required:    false,
+			},
+			"revocation_statements": {
+				Type:        framework.TypeStringSlice,
+				Description: "SQL statements used to drop the user when revoke_user_on_delete is set, templated with {{name}}. Defaults to the engine's revocation statements.",
+				Required:    false,
+			},
+			"rotation_period": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Period between automatic password rotations. Zero disables automatic rotation. Mutually exclusive with rotation_schedule.",
+				Required:    false,
+			},
+			"rotation_schedule": {
+				Type:        framework.TypeString,
+				Description: "Standard cron expression, evaluated in UTC, for automatic password rotations. Mutually exclusive with rotation_period.",
+				Required:    false,
+			},
+			"rotation_window": {
+				Type:        framework.TypeDurationSecond,
+				Description: "How long after each rotation_schedule time a rotation may still start. Must be at least one hour. Zero allows rotation at any time once due.",
+				Required:    false,
+			},
+			"max_autorotate_retries": {
+				Type:        framework.TypeInt,
+				Description: "Number of times a failed automatic rotation is retried with backoff before waiting for the next rotation period. Zero disables retries.",
+				Default:     role.DefaultMaxAutorotateRetries,
 				Required:    false,
 			},
 		},
-		ExistenceCheck: nil,
+		ExistenceCheck: staticRoleExistenceCheck,
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.CreateOperation: &framework.PathOperation{
-				Callback:                    handleStaticRoleWrite,
+				Callback:                    b.handleStaticRoleWrite,
 				ForwardPerformanceSecondary: true,
 				ForwardPerformanceStandby:   true,
 			},
 			logical.UpdateOperation: &framework.PathOperation{
-				Callback:                    handleStaticRoleWrite,
+				Callback:                    b.handleStaticRoleWrite,
 				ForwardPerformanceSecondary: true,
 				ForwardPerformanceStandby:   true,
 			},
-			logical.ReadOperation: &framework.PathOperation{Callback: handleStaticRoleRead},
+			logical.ReadOperation: &framework.PathOperation{Callback: b.handleStaticRoleRead},
 			logical.DeleteOperation: &framework.PathOperation{
-				Callback:                    handleStaticRoleDelete,
+				Callback:                    b.handleStaticRoleDelete,
 				ForwardPerformanceSecondary: true,
 				ForwardPerformanceStandby:   true,
 			},
@@ -69,25 +104,109 @@ func PathStaticRoles() *framework.Path {
 	}
 }
 
-func handleStaticRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+func (b *databaseBackend) handleStaticRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	st := storage.NewBackendStorage(req.Storage)
 
-	roleObj := &role.StaticRole{
-		Name:           d.Get("name").(string),
-		DBType:         d.Get("db_type").(string),
-		ConnectionName: d.Get("connection_name").(string),
-		Username:       d.Get("username").(string),
-		PasswordPolicy: d.Get("password_policy").(string),
+	dbType := d.Get("db_type").(string)
+	name := d.Get("name").(string)
+
+	// Keep the managed credential state of an existing role across updates.
+	defer b.lockStaticRole(dbType, name)()
+	roleObj, err := role.GetStaticRole(ctx, st, dbType, name)
+	if err != nil {
+		return nil, err
+	}
+	isCreate := roleObj == nil
+	if isCreate {
+		roleObj = &role.StaticRole{
+			Name:   name,
+			DBType: dbType,
+		}
 	}
 
+	// The stored password belongs to the account the role was created for,
+	// so the account cannot be changed afterwards.
+	if v, ok := d.GetOk("connection_name"); ok {
+		if !isCreate && v.(string) != roleObj.ConnectionName {
+			return logical.ErrorResponse("cannot update connection_name of an existing static role"), nil
+		}
+		roleObj.ConnectionName = v.(string)
+	}
+	if v, ok := d.GetOk("username"); ok {
+		if !isCreate && v.(string) != roleObj.Username {
+			return logical.ErrorResponse("cannot update username of an existing static role"), nil
+		}
+		roleObj.Username = v.(string)
+	}
+	if v, ok := d.GetOk("password_policy"); ok {
+		roleObj.PasswordPolicy = v.(string)
+	}
 	if v, ok := d.GetOk("rotation_statements"); ok {
 		roleObj.RotationSQL = v.([]string)
 	}
+	if v, ok := d.GetOk("revoke_user_on_delete"); ok {
+		roleObj.RevokeUserOnDelete = v.(bool)
+	}
+	if v, ok := d.GetOk("revocation_statements"); ok {
+		roleObj.RevocationSQL = v.([]string)
+	}
+	period, hasPeriod := d.GetOk("rotation_period")
+	schedule, hasSchedule := d.GetOk("rotation_schedule")
+	if hasPeriod && hasSchedule {
+		return logical.ErrorResponse("rotation_period and rotation_schedule are mutually exclusive"), nil
+	}
+	// Setting one way of scheduling rotations replaces the other.
+	if hasPeriod {
+		roleObj.RotationPeriod = time.Duration(period.(int)) * time.Second
+		roleObj.RotationSchedule = ""
+		roleObj.RotationWindow = 0
+	}
+	if hasSchedule {
+		roleObj.RotationSchedule = schedule.(string)
+		roleObj.RotationPeriod = 0
+	}
+	if v, ok := d.GetOk("rotation_window"); ok {
+		roleObj.RotationWindow = time.Duration(v.(int)) * time.Second
+	}
+	// On create the field's default applies; updates keep the stored value.
+	if _, ok := d.GetOk("max_autorotate_retries"); ok || isCreate {
+		roleObj.MaxAutorotateRetries = d.Get("max_autorotate_retries").(int)
+	}
+
+	if err := roleObj.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err := validateStaticRoleConnection(ctx, req.Storage, roleObj); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	eng, err := b.getEngine(ctx, req.Storage, roleObj.ConnectionName)
+	if err != nil {
+		return nil, err
+	}
+	if len(roleObj.RotationSQL) > 0 || len(roleObj.RevocationSQL) > 0 {
+		if err := eng.Metadata().Require(Engine.FeatureCustomStatements); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+	if roleObj.PasswordPolicy != "" {
+		if _, err := b.generatePassword(ctx, roleObj.PasswordPolicy); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid password_policy: %v", err)), nil
+		}
+	}
 
-	if err := role.CreateOrUpdateStaticRole(ctx, st, roleObj); err != nil {
+	// A new role is rotated right away so Vault knows the current password.
+	if roleObj.LastVaultRotation.IsZero() {
+		if err := b.rotateStaticRole(ctx, req.Storage, roleObj); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to rotate credentials: %v", err)), nil
+		}
+	} else if err := role.CreateOrUpdateStaticRole(ctx, st, roleObj); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("failed to save role: %v", err)), nil
 	}
 
+	if err := b.scheduleStaticRole(roleObj); err != nil {
+		return nil, err
+	}
+
 	return &logical.Response{
 		Data: map[string]interface{}{
 			"message": "Static role saved successfully",
@@ -95,7 +214,34 @@ func handleStaticRoleWrite(ctx context.Context, req *logical.Request, d *framewo
 	}, nil
 }
 
-func handleStaticRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+// validateStaticRoleConnection checks that the role's connection exists, is
+// configured for the role's db_type and allows the role.
+func validateStaticRoleConnection(ctx context.Context, s logical.Storage, r *role.StaticRole) error {
+	if r.ConnectionName == "" {
+		return fmt.Errorf("connection_name is required")
+	}
+	conf, err := storage.LoadConnection(ctx, s, r.ConnectionName)
+	if err != nil {
+		return fmt.Errorf("failed to read connection %q: %w", r.ConnectionName, err)
+	}
+	if conf == nil {
+		return fmt.Errorf("connection %q does not exist", r.ConnectionName)
+	}
+	if conf.PluginName != r.DBType {
+		return fmt.Errorf("connection %q uses plugin %q, not %q", r.ConnectionName, conf.PluginName, r.DBType)
+	}
+	return conf.CheckRoleAllowed(r.ConnectionName, r.Name)
+}
+
+func staticRoleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	r, err := role.GetStaticRole(ctx, storage.NewBackendStorage(req.Storage), d.Get("db_type").(string), d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return r != nil, nil
+}
+
+func (b *databaseBackend) handleStaticRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	st := storage.NewBackendStorage(req.Storage)
 
 	dbType := d.Get("db_type").(string)
@@ -116,42 +262,166 @@ func handleStaticRoleRead(ctx context.Context, req *logical.Request, d *framewor
 		"username":            roleObj.Username,
 		"password_policy":     roleObj.PasswordPolicy,
 		"rotation_statements": roleObj.RotationSQL,
+		"rotation_period":     roleObj.RotationPeriod.Seconds(),
+		"rotation_schedule":   roleObj.RotationSchedule,
+		"rotation_window":     roleObj.RotationWindow.Seconds(),
+		"last_vault_rotation": roleObj.LastVaultRotation,
+
+		"revoke_user_on_delete":      roleObj.RevokeUserOnDelete,
+		"revocation_statements":      roleObj.RevocationSQL,
+		"max_autorotate_retries":     roleObj.MaxAutorotateRetries,
+		"autorotate_failure_retries": roleObj.AutorotateFailureRetries,
+		"last_autorotate_attempt":    roleObj.LastAutorotateAttempt,
+		"last_autorotate_error":      roleObj.LastAutorotateError,
+	}
+	if roleObj.ShouldAutoRotate() {
+		resp["next_vault_rotation"] = roleObj.NextAutorotateTime()
 	}
 
 	return &logical.Response{Data: resp}, nil
 }
 
-func handleStaticRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+func (b *databaseBackend) handleStaticRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	st := storage.NewBackendStorage(req.Storage)
 
 	dbType := d.Get("db_type").(string)
 	name := d.Get("name").(string)
 
+	defer b.lockStaticRole(dbType, name)()
+	roleObj, err := role.GetStaticRole(ctx, st, dbType, name)
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		return nil, nil
+	}
+
+	// Drop the user first so a failure leaves the role, and Vault's
+	// knowledge of the password, in place.
+	if roleObj.RevokeUserOnDelete {
+		if err := b.revokeStaticUser(ctx, req.Storage, roleObj); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to revoke user %q, role not deleted: %v", roleObj.Username, err)), nil
+		}
+	}
+
 	if err := role.DeleteStaticRole(ctx, st, dbType, name); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("failed to delete role: %v", err)), nil
 	}
+	b.unscheduleStaticRole(dbType, name)
 	return &logical.Response{}, nil
 }
 
-// a LIST operation for static roles under a db type
-func PathStaticRoleList() *framework.Path {
+// PathStaticRoleList returns the LIST path for static roles under a db type.
+func PathStaticRoleList(b *databaseBackend) *framework.Path {
+	fields := listPageFields()
+	fields["db_type"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: "Type of the database (e.g. mysql, snowflake).",
+		Required:    true,
+	}
 	return &framework.Path{
-		Pattern: "static-roles/" + framework.GenericNameRegex("db_type") + "$",
+		Pattern: "static-roles/" + framework.GenericNameRegex("db_type") + "/?$",
+		Fields:  fields,
 		Operations: map[logical.Operation]framework.OperationHandler{
-			logical.ListOperation: &framework.PathOperation{Callback: handleStaticRoleList},
+			logical.ListOperation: &framework.PathOperation{Callback: b.handleStaticRoleList},
 		},
 		HelpSynopsis:    "List static roles for a given DB type.",
-		HelpDescription: "Returns a list of static role names configured under the given DB type.",
+		HelpDescription: "Returns static role names configured under the given DB type, with connection details in key_info. Use after and limit to page through large mounts.",
+	}
+}
+
+// PathStaticRoleListAll returns the LIST path for static roles of every db type.
+func PathStaticRoleListAll(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-roles/?$",
+		Fields:  listPageFields(),
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ListOperation: &framework.PathOperation{Callback: b.handleStaticRoleListAll},
+		},
+		HelpSynopsis:    "List static roles across all DB types.",
+		HelpDescription: "Returns static roles as <db_type>/<name> keys, with connection details in key_info. Use after and limit to page through large mounts.",
 	}
 }
 
-func handleStaticRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+func (b *databaseBackend) handleStaticRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	dbType := d.Get("db_type").(string)
-	prefix := fmt.Sprintf("roles/%s/", dbType)
 
-	keys, err := req.Storage.List(ctx, prefix)
+	keys, err := req.Storage.List(ctx, role.StaticRolePath(dbType, ""))
+	if err != nil {
+		return nil, err
+	}
+	keys, err = listPage(d, keys)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	keyInfo := make(map[string]interface{}, len(keys))
+	for _, name := range keys {
+		info, err := staticRoleKeyInfo(ctx, req.Storage, dbType, name)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			keyInfo[name] = info
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *databaseBackend) handleStaticRoleListAll(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	dbTypes, err := req.Storage.List(ctx, staticRolePrefix)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, dbType := range dbTypes {
+		dbType = strings.TrimSuffix(dbType, "/")
+		names, err := req.Storage.List(ctx, role.StaticRolePath(dbType, ""))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			keys = append(keys, staticRoleKey(dbType, name))
+		}
+	}
+	keys, err = listPage(d, keys)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	keyInfo := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		dbType, name, _ := strings.Cut(key, "/")
+		info, err := staticRoleKeyInfo(ctx, req.Storage, dbType, name)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			keyInfo[key] = info
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// staticRoleKeyInfo returns the key_info entry for a static role, or nil if
+// the role no longer exists.
+func staticRoleKeyInfo(ctx context.Context, s logical.Storage, dbType, name string) (map[string]interface{}, error) {
+	r, err := role.GetStaticRole(ctx, storage.NewBackendStorage(s), dbType, name)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	info := map[string]interface{}{
+		"connection_name": r.ConnectionName,
+		"plugin_name":     r.DBType,
+		"ci_name":         "",
+	}
+	conf, err := storage.LoadConnection(ctx, s, r.ConnectionName)
 	if err != nil {
 		return nil, err
 	}
-	return logical.ListResponse(keys), nil
+	if conf != nil {
+		info["plugin_name"] = conf.PluginName
+		info["ci_name"] = conf.CiName
+	}
+	return info, nil
 }
@@ -1,60 +1,139 @@
 package role
 
-//
-//import (
-//	"DatabasePluginVault/storage"
-//	"context"
-//	"encoding/json"
-//	"fmt"
-//)
-//
-//type StaticRole struct {
-//	Name           string   `json:"name"`
-//	DBType         string   `json:"db_type"`
-//	ConnectionName string   `json:"connection_name"`
-//	Username       string   `json:"username"`
-//	PasswordPolicy string   `json:"password_policy,omitempty"`
-//	RotationSQL    []string `json:"rotation_statements"`
-//}
-//
-//func (r *StaticRole) Validate() error {
-//	if r.Name == "" {
-//		return fmt.Errorf("role name cannot be empty")
-//	}
-//	if r.Username == "" {
-//		return fmt.Errorf("username is required")
-//	}
-//	if r.DBType == "" {
-//		return fmt.Errorf("db_type is required")
-//	}
-//	if r.ConnectionName == "" {
-//		return fmt.Errorf("connection_name is required")
-//	}
-//	return nil
-//}
-//
-//func CreateOrUpdateStaticRole(ctx context.Context, s storage.Storage, role *StaticRole) error {
-//	if err := role.Validate(); err != nil {
-//		return err
-//	}
-//	path := fmt.Sprintf("roles/%s/%s", role.DBType, role.Name)
-//	return s.SaveRaw(ctx, path, role)
-//}
-//
-//func GetStaticRole(ctx context.Context, s storage.Storage, dbType, name string) (*StaticRole, error) {
-//	path := fmt.Sprintf("roles/%s/%s", dbType, name)
-//	raw, err := s.LoadRaw(ctx, path)
-//	if err != nil {
-//		return nil, err
-//	}
-//	var r StaticRole
-//	if err := json.Unmarshal(raw, &r); err != nil {
-//		return nil, err
-//	}
-//	return &r, nil
-//}
-//
-//func DeleteStaticRole(ctx context.Context, s storage.Storage, dbType, name string) error {
-//	path := fmt.Sprintf("roles/%s/%s", dbType, name)
-//	return s.DeleteRaw(ctx, path)
-//}
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// MinRotationPeriod is the shortest rotation_period a static role may use.
+	MinRotationPeriod = 5 * time.Second
+
+	// MinRotationWindow is the shortest rotation_window a static role may use.
+	MinRotationWindow = time.Hour
+
+	// DefaultMaxAutorotateRetries is used when a role does not set
+	// max_autorotate_retries.
+	DefaultMaxAutorotateRetries = 5
+
+	// AutorotateBackoffBase is the delay before the first retry of a failed
+	// automatic rotation; it doubles with every further failure.
+	AutorotateBackoffBase = time.Minute
+
+	// AutorotateBackoffMax caps the delay between automatic retries.
+	AutorotateBackoffMax = time.Hour
+)
+
+// Storage is the subset of storage.BackendStorage the role package needs.
+type Storage interface {
+	SaveRaw(ctx context.Context, path string, v interface{}) error
+	LoadRaw(ctx context.Context, path string) ([]byte, error)
+	DeleteRaw(ctx context.Context, path string) error
+}
+
+// StaticRole binds an existing database user to a connection so Vault can
+// manage its credential.
+type StaticRole struct {
+	Name           string   `json:"name"`
+	DBType         string   `json:"db_type"`
+	ConnectionName string   `json:"connection_name"`
+	RotationSQL    []string `json:"rotation_statements"`
+	RevocationSQL  []string `json:"revocation_statements"`
+
+	// MaxAutorotateRetries bounds how often a failed automatic rotation is
+	// retried before waiting for the next rotation period; 0 disables
+	// retries.
+	MaxAutorotateRetries int `json:"max_autorotate_retries"`
+
+	StaticAccount
+}
+
+// NextAutorotateTime returns when the scheduler should next try to rotate the
+// role. After a failed attempt it backs off exponentially, and once the
+// retries are used up it waits for the next regular rotation. Times outside
+// the rotation window move to the start of the next window.
+func (r *StaticRole) NextAutorotateTime() time.Time {
+	failures := r.AutorotateFailureRetries
+	switch {
+	case failures == 0:
+		return r.NextRotationTime()
+	case failures > r.MaxAutorotateRetries && r.RotationSchedule != "":
+		return r.NextScheduledTime(r.LastAutorotateAttempt)
+	case failures > r.MaxAutorotateRetries:
+		return r.LastAutorotateAttempt.Add(r.RotationPeriod)
+	}
+	backoff := AutorotateBackoffMax
+	if failures-1 < 7 {
+		backoff = min(AutorotateBackoffBase<<(failures-1), AutorotateBackoffMax)
+	}
+	return r.NextEligibleTime(r.LastAutorotateAttempt.Add(backoff))
+}
+
+func (r *StaticRole) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("role name cannot be empty")
+	}
+	if r.Username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if r.DBType == "" {
+		return fmt.Errorf("db_type is required")
+	}
+	if r.ConnectionName == "" {
+		return fmt.Errorf("connection_name is required")
+	}
+	if r.RotationPeriod != 0 && r.RotationPeriod < MinRotationPeriod {
+		return fmt.Errorf("rotation_period must be at least %s", MinRotationPeriod)
+	}
+	if r.RotationPeriod != 0 && r.RotationSchedule != "" {
+		return fmt.Errorf("rotation_period and rotation_schedule are mutually exclusive")
+	}
+	if r.RotationSchedule != "" {
+		if _, err := ParseRotationSchedule(r.RotationSchedule); err != nil {
+			return err
+		}
+	}
+	if r.RotationWindow != 0 {
+		if r.RotationSchedule == "" {
+			return fmt.Errorf("rotation_window requires rotation_schedule")
+		}
+		if r.RotationWindow < MinRotationWindow {
+			return fmt.Errorf("rotation_window must be at least %s", MinRotationWindow)
+		}
+	}
+	if r.MaxAutorotateRetries < 0 {
+		return fmt.Errorf("max_autorotate_retries cannot be negative")
+	}
+	return nil
+}
+
+// StaticRolePath is the storage key for a static role.
+func StaticRolePath(dbType, name string) string {
+	return fmt.Sprintf("static-roles/%s/%s", dbType, name)
+}
+
+func CreateOrUpdateStaticRole(ctx context.Context, s Storage, role *StaticRole) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+	return s.SaveRaw(ctx, StaticRolePath(role.DBType, role.Name), role)
+}
+
+// GetStaticRole returns the stored role, or nil if it does not exist.
+func GetStaticRole(ctx context.Context, s Storage, dbType, name string) (*StaticRole, error) {
+	raw, err := s.LoadRaw(ctx, StaticRolePath(dbType, name))
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var r StaticRole
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func DeleteStaticRole(ctx context.Context, s Storage, dbType, name string) error {
+	return s.DeleteRaw(ctx, StaticRolePath(dbType, name))
+}
@@ -1,9 +1,11 @@
 package role
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/robfig/cron/v3"
 )
 
 // RoleEntry represents a dynamic or static role in the system.
@@ -15,6 +17,21 @@ type RoleEntry struct {
 	CredentialType   dbplugin.CredentialType `json:"credential_type"`
 	CredentialConfig map[string]interface{}  `json:"credential_config"`
 	StaticAccount    *StaticAccount          `json:"static_account" mapstructure:"static_account"`
+	UsernameTemplate string                  `json:"username_template"`
+}
+
+// Validate checks the fields a dynamic role needs before it can issue credentials.
+func (r *RoleEntry) Validate() error {
+	if r.DBName == "" {
+		return fmt.Errorf("db_name is required")
+	}
+	if r.DefaultTTL < 0 || r.MaxTTL < 0 {
+		return fmt.Errorf("ttls cannot be negative")
+	}
+	if r.MaxTTL > 0 && r.DefaultTTL > r.MaxTTL {
+		return fmt.Errorf("default_ttl cannot be greater than max_ttl")
+	}
+	return nil
 }
 
 // StaticAccount represents a statically managed credential.
@@ -29,13 +46,65 @@ type StaticAccount struct {
 	AutorotateFailureRetries int           `json:"autorotate_failure_retries"`
 	LastAutorotateAttempt    time.Time     `json:"last_autorotate_attempt"`
 	LastAutorotateError      string        `json:"last_autorotate_error"`
+
+	// RotationSchedule is a standard cron expression, evaluated in UTC,
+	// used instead of RotationPeriod.
+	RotationSchedule string `json:"rotation_schedule"`
+	// RotationWindow is how long after each scheduled time a rotation may
+	// still start. Zero means rotations may start at any time once due.
+	RotationWindow time.Duration `json:"rotation_window"`
+}
+
+// ParseRotationSchedule parses a rotation_schedule cron expression.
+func ParseRotationSchedule(expr string) (cron.Schedule, error) {
+	sched, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rotation_schedule %q: %w", expr, err)
+	}
+	return sched, nil
+}
+
+// ShouldAutoRotate reports whether the account is rotated by the scheduler.
+func (s *StaticAccount) ShouldAutoRotate() bool {
+	return s.RotationPeriod > 0 || s.RotationSchedule != ""
 }
 
 // NextRotationTime returns when the static credential is due for rotation.
 func (s *StaticAccount) NextRotationTime() time.Time {
+	if s.RotationSchedule != "" {
+		return s.NextScheduledTime(s.LastVaultRotation)
+	}
 	return s.LastVaultRotation.Add(s.RotationPeriod)
 }
 
+// NextScheduledTime returns the first rotation_schedule activation after t.
+// Schedules are validated on write, so an unparsable one never fires.
+func (s *StaticAccount) NextScheduledTime(t time.Time) time.Time {
+	sched, err := ParseRotationSchedule(s.RotationSchedule)
+	if err != nil {
+		return time.Unix(1<<62, 0)
+	}
+	return sched.Next(t.UTC())
+}
+
+// InRotationWindow reports whether a rotation may start at t, i.e. whether
+// a scheduled time falls within the rotation window before t.
+func (s *StaticAccount) InRotationWindow(t time.Time) bool {
+	if s.RotationSchedule == "" || s.RotationWindow <= 0 {
+		return true
+	}
+	return !s.NextScheduledTime(t.Add(-s.RotationWindow)).After(t)
+}
+
+// NextEligibleTime returns t if a rotation may start then, otherwise the
+// start of the next rotation window.
+func (s *StaticAccount) NextEligibleTime(t time.Time) time.Time {
+	if s.InRotationWindow(t) {
+		return t
+	}
+	return s.NextScheduledTime(t)
+}
+
 // CredentialTTL calculates how long this credential is still valid.
 func (s *StaticAccount) CredentialTTL() time.Duration {
 	return time.Until(s.NextRotationTime())
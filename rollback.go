@@ -0,0 +1,188 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/dbengines"
+	"DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/role"
+	"DatabasePluginVault/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// staticWALKind marks WAL entries written before a static role rotation.
+	staticWALKind = "staticRotation"
+
+	// rootWALKind marks WAL entries written before a root credential rotation.
+	rootWALKind = "rootRotation"
+
+	// walRollbackMinAge is how old a WAL entry must be before it is treated
+	// as left behind by a crashed rotation.
+	walRollbackMinAge = 1 * time.Minute
+)
+
+// staticRotationWAL records a static role password before it is applied.
+type staticRotationWAL struct {
+	DBType      string `json:"db_type" mapstructure:"db_type"`
+	Name        string `json:"name" mapstructure:"name"`
+	Username    string `json:"username" mapstructure:"username"`
+	NewPassword string `json:"new_password" mapstructure:"new_password"`
+	// CreatedAt lets rollback tell whether a later rotation replaced the
+	// password.
+	CreatedAt time.Time `json:"created_at" mapstructure:"created_at"`
+}
+
+// rootRotationWAL records a root password before it is applied.
+type rootRotationWAL struct {
+	ConnectionName string `json:"connection_name" mapstructure:"connection_name"`
+	Username       string `json:"username" mapstructure:"username"`
+	NewPassword    string `json:"new_password" mapstructure:"new_password"`
+}
+
+// walRollback is called by Vault for WAL entries a rotation never deleted.
+// Returning nil lets Vault delete the entry.
+func (b *databaseBackend) walRollback(ctx context.Context, req *logical.Request, kind string, data interface{}) error {
+	switch kind {
+	case staticWALKind:
+		var entry staticRotationWAL
+		if err := decodeWAL(data, &entry); err != nil {
+			return err
+		}
+		return b.rollbackStaticRotation(ctx, req.Storage, &entry)
+	case rootWALKind:
+		var entry rootRotationWAL
+		if err := decodeWAL(data, &entry); err != nil {
+			return err
+		}
+		return b.rollbackRootRotation(ctx, req.Storage, &entry)
+	default:
+		return fmt.Errorf("unknown WAL entry kind %q", kind)
+	}
+}
+
+// decodeWAL decodes the data of a WAL entry read back from storage.
+func decodeWAL(data interface{}, out interface{}) error {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		Result:     out,
+	})
+	if err != nil {
+		return err
+	}
+	return dec.Decode(data)
+}
+
+// findStaticWAL returns the WAL entry left by an earlier failed rotation of
+// r, if there is one.
+func findStaticWAL(ctx context.Context, s logical.Storage, r *role.StaticRole) (string, *staticRotationWAL, error) {
+	ids, err := framework.ListWAL(ctx, s)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list WAL entries: %w", err)
+	}
+	for _, id := range ids {
+		wal, err := framework.GetWAL(ctx, s, id)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read WAL entry: %w", err)
+		}
+		if wal == nil || wal.Kind != staticWALKind {
+			continue
+		}
+		var entry staticRotationWAL
+		if err := decodeWAL(wal.Data, &entry); err != nil {
+			return "", nil, err
+		}
+		if entry.DBType != r.DBType || entry.Name != r.Name || entry.Username != r.Username {
+			continue
+		}
+		if r.LastVaultRotation.After(entry.CreatedAt) {
+			continue
+		}
+		return id, &entry, nil
+	}
+	return "", nil, nil
+}
+
+// errStaticRotationPending keeps the WAL entry of a static rotation that has
+// not been completed yet.
+var errStaticRotationPending = errors.New("static role rotation is pending")
+
+// rollbackStaticRotation drops WAL entries whose rotation has been superseded
+// and keeps the others. It never applies the password itself: the role's
+// next rotation finds the entry through findStaticWAL and retries the same
+// password, within the role's backoff and max_autorotate_retries.
+func (b *databaseBackend) rollbackStaticRotation(ctx context.Context, s logical.Storage, entry *staticRotationWAL) error {
+	defer b.lockStaticRole(entry.DBType, entry.Name)()
+
+	r, err := role.GetStaticRole(ctx, storage.NewBackendStorage(s), entry.DBType, entry.Name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case r == nil:
+	case r.Username != entry.Username:
+	case r.Password == entry.NewPassword:
+	case r.LastVaultRotation.After(entry.CreatedAt):
+		// A rotation after this entry was written set a newer password.
+	default:
+		key := staticRoleKey(r.DBType, r.Name)
+		if r.ShouldAutoRotate() {
+			return fmt.Errorf("%w for %q, retrying at %s", errStaticRotationPending, key, r.NextAutorotateTime().Format(time.RFC3339))
+		}
+		return fmt.Errorf("%w for %q, rotate it with rotate-role/%s", errStaticRotationPending, key, key)
+	}
+	return nil
+}
+
+// rollbackRootRotation stores the recorded root password if the database
+// accepts it. If the database rejects it the old password is still in
+// effect and the entry is dropped; any other failure keeps the entry so the
+// check is repeated.
+func (b *databaseBackend) rollbackRootRotation(ctx context.Context, s logical.Storage, entry *rootRotationWAL) error {
+	defer b.lockConnection(entry.ConnectionName)()
+
+	config, err := storage.LoadConnection(ctx, s, entry.ConnectionName)
+	if err != nil {
+		return err
+	}
+	if config == nil || config.ConnectionDetails["password"] == entry.NewPassword {
+		return nil
+	}
+
+	details := make(map[string]interface{}, len(config.ConnectionDetails))
+	for k, v := range config.ConnectionDetails {
+		details[k] = v
+	}
+	details["password"] = entry.NewPassword
+
+	eng, err := dbengines.New(config.PluginName, details)
+	if err != nil {
+		return err
+	}
+	_, err = eng.Connect(ctx)
+	eng.Close()
+	if errors.Is(err, Engine.ErrAuthentication) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	config.ConnectionDetails = details
+	if err := b.storeConfig(ctx, s, entry.ConnectionName, config); err != nil {
+		return err
+	}
+	return b.swapEngine(entry.ConnectionName, config)
+}
+
+// deleteWAL removes a WAL entry once its rotation has been persisted.
+func (b *databaseBackend) deleteWAL(ctx context.Context, s logical.Storage, id string) {
+	if err := framework.DeleteWAL(ctx, s, id); err != nil {
+		b.logger.Warn("failed to delete WAL entry", "id", id, "error", err)
+	}
+}
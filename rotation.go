@@ -0,0 +1,427 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/dbengines"
+	"DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/internal/notify"
+	pwgen "DatabasePluginVault/internal/password"
+	"DatabasePluginVault/role"
+	"DatabasePluginVault/storage"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/queue"
+)
+
+// staticRolePrefix is the storage prefix under which static roles live.
+const staticRolePrefix = "static-roles/"
+
+// staticRoleKey is the rotation queue key for a static role.
+func staticRoleKey(dbType, name string) string {
+	return dbType + "/" + name
+}
+
+// scheduleStaticRole (re)places the role in the rotation queue at its next
+// rotation time. Roles without a rotation period or schedule are only removed.
+func (b *databaseBackend) scheduleStaticRole(r *role.StaticRole) error {
+	key := staticRoleKey(r.DBType, r.Name)
+	if _, err := b.queue.PopByKey(key); err != nil {
+		return err
+	}
+	if !r.ShouldAutoRotate() {
+		return nil
+	}
+	return b.queue.Push(&queue.Item{
+		Key:      key,
+		Priority: r.NextAutorotateTime().Unix(),
+	})
+}
+
+// lockStaticRole takes the role's lock and returns the func releasing it.
+// Callers must load the role after locking so they act on its latest state.
+func (b *databaseBackend) lockStaticRole(dbType, name string) func() {
+	l := locksutil.LockForKey(b.roleLocks, staticRoleKey(dbType, name))
+	l.Lock()
+	return l.Unlock
+}
+
+// lockConnection takes the connection's lock and returns the func releasing
+// it. Callers must load the config after locking.
+func (b *databaseBackend) lockConnection(name string) func() {
+	l := locksutil.LockForKey(b.connLocks, name)
+	l.Lock()
+	return l.Unlock
+}
+
+// unscheduleStaticRole drops the role from the rotation queue.
+func (b *databaseBackend) unscheduleStaticRole(dbType, name string) {
+	b.queue.PopByKey(staticRoleKey(dbType, name))
+}
+
+// initQueue rebuilds the rotation queue from storage so schedules survive a
+// plugin restart. Only nodes that can write storage rotate, so the queue
+// stays empty on performance standbys and secondaries.
+func (b *databaseBackend) initQueue(ctx context.Context, req *logical.InitializationRequest) error {
+	if !b.WriteSafeReplicationState() {
+		return nil
+	}
+	st := storage.NewBackendStorage(req.Storage)
+
+	dbTypes, err := req.Storage.List(ctx, staticRolePrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list static role types: %w", err)
+	}
+	// A role that cannot be loaded is skipped so the others still rotate.
+	var merr error
+	for _, dbType := range dbTypes {
+		dbType = strings.TrimSuffix(dbType, "/")
+		names, err := req.Storage.List(ctx, role.StaticRolePath(dbType, ""))
+		if err != nil {
+			b.logger.Error("failed to list static roles", "db_type", dbType, "error", err)
+			merr = errors.Join(merr, fmt.Errorf("failed to list %s static roles: %w", dbType, err))
+			continue
+		}
+		for _, name := range names {
+			key := staticRoleKey(dbType, name)
+			r, err := role.GetStaticRole(ctx, st, dbType, name)
+			if err == nil && r != nil {
+				err = b.scheduleStaticRole(r)
+			}
+			if err != nil {
+				b.logger.Error("failed to schedule static role", "role", key, "error", err)
+				merr = errors.Join(merr, fmt.Errorf("failed to schedule static role %s: %w", key, err))
+			}
+		}
+	}
+	return merr
+}
+
+// periodicFunc rotates every static role whose next rotation time has passed.
+// Failed rotations are recorded on the role, retried with backoff and
+// returned so Vault logs them.
+func (b *databaseBackend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	// A rotation here would change the password in the database and then
+	// fail to store it in read-only storage.
+	if !b.WriteSafeReplicationState() {
+		return nil
+	}
+	now := time.Now().Unix()
+
+	// Collect due items first so failed roles put back on the queue are not
+	// picked up again in the same run.
+	var due []*queue.Item
+	for b.queue.Len() > 0 {
+		item, err := b.queue.Pop()
+		if err != nil {
+			return err
+		}
+		if item.Priority > now {
+			if err := b.queue.Push(item); err != nil {
+				return err
+			}
+			break
+		}
+		due = append(due, item)
+	}
+
+	var merr error
+	for _, item := range due {
+		merr = errors.Join(merr, b.autorotateStaticRole(ctx, req.Storage, item))
+	}
+	return merr
+}
+
+// autorotateStaticRole rotates the role behind a due queue item under the
+// role's lock.
+func (b *databaseBackend) autorotateStaticRole(ctx context.Context, s logical.Storage, item *queue.Item) error {
+	dbType, name, _ := strings.Cut(item.Key, "/")
+	defer b.lockStaticRole(dbType, name)()
+
+	r, err := role.GetStaticRole(ctx, storage.NewBackendStorage(s), dbType, name)
+	if err != nil {
+		b.logger.Error("failed to load static role", "role", item.Key, "error", err)
+		return b.queue.Push(item)
+	}
+	if r == nil {
+		// deleted since it was scheduled
+		return nil
+	}
+	// A manual rotation or a role update may have landed between popping
+	// the item and taking the lock.
+	if !r.ShouldAutoRotate() || r.NextAutorotateTime().Unix() > time.Now().Unix() {
+		return b.scheduleStaticRole(r)
+	}
+	if !r.InRotationWindow(time.Now()) {
+		next := r.NextEligibleTime(time.Now())
+		b.logger.Info("outside rotation window, skipping", "role", item.Key, "next_eligible", next)
+		item.Priority = next.Unix()
+		return b.queue.Push(item)
+	}
+	var rerr error
+	if err := b.rotateStaticRole(ctx, s, r); err != nil {
+		rerr = b.recordAutorotateFailure(ctx, s, r, err)
+	}
+	if err := b.scheduleStaticRole(r); err != nil {
+		b.logger.Error("failed to reschedule static role", "role", item.Key, "error", err)
+	}
+	return rerr
+}
+
+// recordAutorotateFailure saves the failed attempt on the role so the next
+// schedule backs off and static-roles reads show the error.
+func (b *databaseBackend) recordAutorotateFailure(ctx context.Context, s logical.Storage, r *role.StaticRole, rotateErr error) error {
+	key := staticRoleKey(r.DBType, r.Name)
+
+	r.AutorotateFailureRetries++
+	r.LastAutorotateAttempt = time.Now()
+	r.LastAutorotateError = rotateErr.Error()
+	if err := role.CreateOrUpdateStaticRole(ctx, storage.NewBackendStorage(s), r); err != nil {
+		b.logger.Error("failed to record rotation failure", "role", key, "error", err)
+	}
+
+	if r.AutorotateFailureRetries > r.MaxAutorotateRetries {
+		b.logger.Error("automatic rotation retries exhausted, waiting for next rotation period",
+			"role", key, "failures", r.AutorotateFailureRetries, "next_attempt", r.NextAutorotateTime(), "error", rotateErr)
+	} else {
+		b.logger.Warn("automatic rotation failed, retrying with backoff",
+			"role", key, "failures", r.AutorotateFailureRetries, "next_attempt", r.NextAutorotateTime(), "error", rotateErr)
+	}
+	return fmt.Errorf("failed to rotate static role %q (attempt %d): %w", key, r.AutorotateFailureRetries, rotateErr)
+}
+
+// rotateStaticRole sets a freshly generated password on the role's database
+// user and persists it along with the rotation time. The new password is
+// recorded in a WAL entry first so a crash between the database change and
+// the storage write can be recovered by walRollback.
+func (b *databaseBackend) rotateStaticRole(ctx context.Context, s logical.Storage, r *role.StaticRole) error {
+	conf, err := storage.LoadConnection(ctx, s, r.ConnectionName)
+	if err != nil {
+		return err
+	}
+	if conf == nil {
+		return fmt.Errorf("connection %q does not exist", r.ConnectionName)
+	}
+	if err := conf.CheckRoleAllowed(r.ConnectionName, r.Name); err != nil {
+		return err
+	}
+
+	err = b.setStaticPassword(ctx, s, r, conf)
+	b.notifyRotation(ctx, s, conf.Emails, &notify.Event{
+		Kind:       notify.KindStaticRole,
+		Connection: r.ConnectionName,
+		Role:       staticRoleKey(r.DBType, r.Name),
+		Username:   r.Username,
+		Time:       time.Now(),
+		Err:        err,
+	})
+	return err
+}
+
+func (b *databaseBackend) setStaticPassword(ctx context.Context, s logical.Storage, r *role.StaticRole, conf *storage.DatabaseConfig) error {
+	eng, err := b.getEngine(ctx, s, r.ConnectionName)
+	if err != nil {
+		return err
+	}
+
+	// A failed rotation may still have changed the password, so its WAL
+	// entry is kept and its password is tried again before a new one.
+	walID, pending, err := findStaticWAL(ctx, s, r)
+	if err != nil {
+		return err
+	}
+	var password string
+	if pending != nil {
+		password = pending.NewPassword
+	} else {
+		password, err = b.generatePassword(ctx, pwgen.Policy(r.PasswordPolicy, conf.PasswordPolicy))
+		if err != nil {
+			return err
+		}
+		walID, err = framework.PutWAL(ctx, s, staticWALKind, &staticRotationWAL{
+			DBType:      r.DBType,
+			Name:        r.Name,
+			Username:    r.Username,
+			NewPassword: password,
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write WAL entry: %w", err)
+		}
+	}
+
+	if err := applyStaticCredential(ctx, eng, r, password); err != nil {
+		return err
+	}
+
+	r.Password = password
+	r.LastVaultRotation = time.Now()
+	r.AutorotateFailureRetries = 0
+	r.LastAutorotateError = ""
+	if err := role.CreateOrUpdateStaticRole(ctx, storage.NewBackendStorage(s), r); err != nil {
+		return err
+	}
+	b.deleteWAL(ctx, s, walID)
+	return nil
+}
+
+// applyStaticCredential sets password on the role's database user, using the
+// role's rotation statements when present and the engine default otherwise.
+func applyStaticCredential(ctx context.Context, eng Engine.Engine, r *role.StaticRole, password string) error {
+	err := eng.UpdateUser(ctx, Engine.UpdateUserRequest{
+		Username:   r.Username,
+		Password:   password,
+		Statements: r.RotationSQL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user %q: %w", r.Username, err)
+	}
+	return nil
+}
+
+// revokeStaticUser drops the role's database user on its connection.
+func (b *databaseBackend) revokeStaticUser(ctx context.Context, s logical.Storage, r *role.StaticRole) error {
+	eng, err := b.getEngine(ctx, s, r.ConnectionName)
+	if err != nil {
+		return err
+	}
+	return eng.DeleteUser(ctx, Engine.DeleteUserRequest{
+		Username:   r.Username,
+		Statements: r.RevocationSQL,
+	})
+}
+
+// rotateRootCredentials sets a new password on the connection's user, stores
+// it in the connection config and swaps in an Engine that uses it.
+func (b *databaseBackend) rotateRootCredentials(ctx context.Context, s logical.Storage, name string, config *storage.DatabaseConfig) error {
+	username, _ := config.ConnectionDetails["username"].(string)
+	if username == "" {
+		return fmt.Errorf("connection %q has no username to rotate", name)
+	}
+
+	err := b.setRootPassword(ctx, s, name, config, username)
+	b.notifyRotation(ctx, s, config.Emails, &notify.Event{
+		Kind:       notify.KindRoot,
+		Connection: name,
+		Username:   username,
+		Time:       time.Now(),
+		Err:        err,
+	})
+	return err
+}
+
+func (b *databaseBackend) setRootPassword(ctx context.Context, s logical.Storage, name string, config *storage.DatabaseConfig, username string) error {
+	eng, err := b.getEngine(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	password, err := b.generatePassword(ctx, config.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+
+	walID, err := framework.PutWAL(ctx, s, rootWALKind, &rootRotationWAL{
+		ConnectionName: name,
+		Username:       username,
+		NewPassword:    password,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write WAL entry: %w", err)
+	}
+
+	err = eng.UpdateUser(ctx, Engine.UpdateUserRequest{
+		Username:   username,
+		Password:   password,
+		Statements: config.RootCredentialsRotateStatements,
+	})
+	if err != nil {
+		// The WAL entry stays so walRollback can check whether the database
+		// took the new password before reporting the error.
+		return err
+	}
+
+	config.ConnectionDetails["password"] = password
+	if err := b.storeConfig(ctx, s, name, config); err != nil {
+		return err
+	}
+	b.deleteWAL(ctx, s, walID)
+
+	return b.swapEngine(name, config)
+}
+
+// notifyRotation mails the outcome of a rotation to the connection's emails
+// if an SMTP server is configured. Delivery runs in the background so a slow
+// mail server never holds up a rotation.
+func (b *databaseBackend) notifyRotation(ctx context.Context, s logical.Storage, emails []string, e *notify.Event) {
+	if len(emails) == 0 {
+		return
+	}
+	cfg, err := storage.LoadSMTPConfig(ctx, s)
+	if err != nil {
+		b.logger.Warn("failed to load smtp config", "error", err)
+		return
+	}
+	if cfg == nil {
+		return
+	}
+	n, err := b.newNotifier(cfg)
+	if err != nil {
+		b.logger.Warn("invalid smtp config", "error", err)
+		return
+	}
+
+	go func() {
+		if err := n.Notify(context.Background(), emails, e); err != nil {
+			b.logger.Warn("failed to send rotation notification", "subject", e.Subject(), "error", err)
+		}
+	}()
+}
+
+// newSMTPNotifier is the default databaseBackend.newNotifier.
+func newSMTPNotifier(cfg *notify.SMTPConfig) (notify.Notifier, error) {
+	n, err := notify.NewSMTPNotifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// swapEngine replaces the cached Engine for name with one built from config.
+func (b *databaseBackend) swapEngine(name string, config *storage.DatabaseConfig) error {
+	eng, err := dbengines.New(config.PluginName, config.ConnectionDetails)
+	if err != nil {
+		b.conn.ClearConnection(name)
+		return err
+	}
+	if old := b.conn.Put(name, eng); old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// generatePassword returns a password from the named Vault password policy,
+// or from the built-in generator when no policy is set.
+func (b *databaseBackend) generatePassword(ctx context.Context, policy string) (string, error) {
+	return pwgen.Generate(ctx, b.System(), policy)
+}
+
+// getEngine returns the cached Engine for a connection, building it from the
+// stored config if it is not cached yet, e.g. after a restart or an
+// invalidation on a standby.
+func (b *databaseBackend) getEngine(ctx context.Context, s logical.Storage, name string) (Engine.Engine, error) {
+	return b.conn.GetOrCreate(name, func() (Engine.Engine, error) {
+		conf, err := storage.LoadConnection(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		if conf == nil {
+			return nil, fmt.Errorf("connection %q does not exist", name)
+		}
+		return dbengines.New(conf.PluginName, conf.ConnectionDetails)
+	})
+}
@@ -0,0 +1,146 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/notify"
+	"DatabasePluginVault/role"
+	"DatabasePluginVault/storage"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// recordingNotifier hands every event it is asked to send to a channel.
+type recordingNotifier struct {
+	events chan *notify.Event
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, to []string, e *notify.Event) error {
+	n.events <- e
+	return nil
+}
+
+// nextEvent waits for the event of the rotation that just ran.
+func (n *recordingNotifier) nextEvent(t *testing.T) *notify.Event {
+	t.Helper()
+	select {
+	case e := <-n.events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification was sent")
+		return nil
+	}
+}
+
+// newTestBackend returns a backend on in-memory storage whose rotation mails
+// are recorded instead of sent, and a SQLite connection named "local" that
+// mails ops@example.com.
+func newTestBackend(t *testing.T) (*databaseBackend, logical.Storage, *recordingNotifier) {
+	t.Helper()
+	ctx := context.Background()
+	conf := logical.TestBackendConfig()
+	conf.StorageView = &logical.InmemStorage{}
+	b := Backend(conf)
+	if err := b.Setup(ctx, conf); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.conn.ClearAll)
+
+	rec := &recordingNotifier{events: make(chan *notify.Event, 1)}
+	b.newNotifier = func(*notify.SMTPConfig) (notify.Notifier, error) {
+		return rec, nil
+	}
+
+	s := conf.StorageView
+	err := storage.SaveSMTPConfig(ctx, s, &notify.SMTPConfig{Host: "mail.example.com", Port: 25, From: "vault@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.storeConfig(ctx, s, "local", &storage.DatabaseConfig{
+		PluginName: "sqlite",
+		ConnectionDetails: map[string]interface{}{
+			"connection_url": filepath.Join(t.TempDir(), "vault.db"),
+			"username":       "root",
+		},
+		AllowedRoles: []string{"*"},
+		Emails:       []string{"ops@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, s, rec
+}
+
+func TestRotateStaticRole_Notifies(t *testing.T) {
+	ctx := context.Background()
+	b, s, rec := newTestBackend(t)
+
+	r := &role.StaticRole{
+		Name:           "app",
+		DBType:         "sqlite",
+		ConnectionName: "local",
+		StaticAccount:  role.StaticAccount{Username: "app"},
+	}
+	if err := b.rotateStaticRole(ctx, s, r); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	e := rec.nextEvent(t)
+	if e.Kind != notify.KindStaticRole || e.Connection != "local" || e.Role != "sqlite/app" || e.Username != "app" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if !e.Success() {
+		t.Fatalf("expected success, got %v", e.Err)
+	}
+
+	r.RotationSQL = []string{"UPDATE no_such_table SET password = '{{password}}'"}
+	if err := b.rotateStaticRole(ctx, s, r); err == nil {
+		t.Fatal("expected rotation to fail")
+	}
+	e = rec.nextEvent(t)
+	if e.Kind != notify.KindStaticRole || e.Role != "sqlite/app" || e.Success() {
+		t.Fatalf("expected a failure event, got %+v", e)
+	}
+}
+
+func TestRotateRootCredentials_Notifies(t *testing.T) {
+	ctx := context.Background()
+	b, s, rec := newTestBackend(t)
+
+	config, err := storage.LoadConnection(ctx, s, "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.rotateRootCredentials(ctx, s, "local", config); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	e := rec.nextEvent(t)
+	if e.Kind != notify.KindRoot || e.Connection != "local" || e.Username != "root" || e.Role != "" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if !e.Success() {
+		t.Fatalf("expected success, got %v", e.Err)
+	}
+
+	config.RootCredentialsRotateStatements = []string{"UPDATE no_such_table SET password = '{{password}}'"}
+	if err := b.rotateRootCredentials(ctx, s, "local", config); err == nil {
+		t.Fatal("expected rotation to fail")
+	}
+	e = rec.nextEvent(t)
+	if e.Kind != notify.KindRoot || e.Connection != "local" || e.Success() {
+		t.Fatalf("expected a failure event, got %+v", e)
+	}
+}
+
+func TestNotifyRotation_NoEmails(t *testing.T) {
+	ctx := context.Background()
+	b, s, rec := newTestBackend(t)
+
+	b.notifyRotation(ctx, s, nil, &notify.Event{Kind: notify.KindRoot, Connection: "local"})
+	select {
+	case e := <-rec.events:
+		t.Fatalf("notified without recipients: %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
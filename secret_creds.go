@@ -0,0 +1,119 @@
+package dbsecretengine
+
+import (
+	"DatabasePluginVault/internal/dbengines/Engine"
+	"DatabasePluginVault/storage"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// SecretCredsType is the secret type for users issued by creds/<name>.
+const SecretCredsType = "creds"
+
+// renewExpirationSlack is added to the database expiry set on renewal.
+const renewExpirationSlack = 5 * time.Second
+
+func secretCreds(b *databaseBackend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretCredsType,
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username of the database user.",
+			},
+			"password": {
+				Type:        framework.TypeString,
+				Description: "Password of the database user, for password roles.",
+			},
+			"rsa_private_key": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded private key of the database user, for rsa_private_key roles.",
+			},
+			"client_certificate": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded client certificate of the database user, for client_certificate roles.",
+			},
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded private key of the client certificate.",
+			},
+		},
+		Renew:  b.secretCredsRenew,
+		Revoke: b.secretCredsRevoke,
+	}
+}
+
+// secretCredsRenew extends the lease by the role's default TTL; Vault caps
+// the result at the role's MaxTTL. The user's expiry in the database, such
+// as a PostgreSQL VALID UNTIL, is moved along with the lease.
+func (b *databaseBackend) secretCredsRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName, ok := req.Secret.InternalData["role"].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing role internal data")
+	}
+	username, ok := req.Secret.InternalData["username"].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing username internal data")
+	}
+	dbName, ok := req.Secret.InternalData["db_name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing db_name internal data")
+	}
+	roleObj, err := storage.LoadRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if roleObj == nil {
+		return nil, fmt.Errorf("error during renew: could not find role with name %q", roleName)
+	}
+
+	ttl, _, err := framework.CalculateTTL(b.System(), req.Secret.Increment, roleObj.DefaultTTL, 0, roleObj.MaxTTL, 0, req.Secret.IssueTime)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		eng, err := b.getEngine(ctx, req.Storage, dbName)
+		if err != nil {
+			return nil, err
+		}
+		// Vault recalculates the TTL after this call, so leave a few seconds
+		// for the user to outlive the lease.
+		err = eng.UpdateUser(ctx, Engine.UpdateUserRequest{
+			Username:   username,
+			Expiration: time.Now().Add(ttl + renewExpirationSlack),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to extend expiration of user %q: %w", username, err)
+		}
+	}
+
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = roleObj.DefaultTTL
+	resp.Secret.MaxTTL = roleObj.MaxTTL
+	return resp, nil
+}
+
+// secretCredsRevoke drops the database user behind the lease.
+func (b *databaseBackend) secretCredsRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username, ok := req.Secret.InternalData["username"].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing username internal data")
+	}
+	dbName, ok := req.Secret.InternalData["db_name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing db_name internal data")
+	}
+
+	eng, err := b.getEngine(ctx, req.Storage, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if err := eng.DeleteUser(ctx, Engine.DeleteUserRequest{Username: username}); err != nil {
+		return nil, fmt.Errorf("failed to revoke user %q: %w", username, err)
+	}
+	return nil, nil
+}
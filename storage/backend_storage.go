@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// BackendStorage wraps a logical.Storage with raw JSON helpers so packages
+// that cannot import storage (e.g. role) can persist through it.
+type BackendStorage struct {
+	s logical.Storage
+}
+
+// NewBackendStorage wraps the request storage.
+func NewBackendStorage(s logical.Storage) *BackendStorage {
+	return &BackendStorage{s: s}
+}
+
+// SaveRaw JSON-encodes v and stores it under path.
+func (b *BackendStorage) SaveRaw(ctx context.Context, path string, v interface{}) error {
+	entry, err := logical.StorageEntryJSON(path, v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %q: %w", path, err)
+	}
+	return b.s.Put(ctx, entry)
+}
+
+// LoadRaw returns the raw value stored under path, or nil if there is none.
+func (b *BackendStorage) LoadRaw(ctx context.Context, path string) ([]byte, error) {
+	entry, err := b.s.Get(ctx, path)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+// DeleteRaw removes the entry stored under path.
+func (b *BackendStorage) DeleteRaw(ctx context.Context, path string) error {
+	return b.s.Delete(ctx, path)
+}
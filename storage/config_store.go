@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -19,6 +20,22 @@ type DatabaseConfig struct {
 	PasswordPolicy                  string   `json:"password_policy" structs:"password_policy" mapstructure:"password_policy"`
 	CiName                          string   `json:"ci_name" structs:"ci_name" mapstructure:"ci_name"`
 	Emails                          []string `json:"emails" structs:"emails" mapstructure:"emails"`
+	UsernameTemplate                string   `json:"username_template" structs:"username_template" mapstructure:"username_template"`
+}
+
+// AllowsRole reports whether the role name matches an entry in
+// AllowedRoles. Entries may be globs such as "app-*"; "*" allows every role
+// and an empty list allows none.
+func (c *DatabaseConfig) AllowsRole(name string) bool {
+	return strutil.StrListContainsGlob(c.AllowedRoles, name)
+}
+
+// CheckRoleAllowed returns an error if the connection does not allow the role.
+func (c *DatabaseConfig) CheckRoleAllowed(connection, role string) error {
+	if !c.AllowsRole(role) {
+		return fmt.Errorf("role %q is not permitted on connection %q: add it to the connection's allowed_roles", role, connection)
+	}
+	return nil
 }
 
 func ConfigPath(dbType, name string) string {
@@ -48,3 +65,21 @@ func LoadDBConfig[T any](ctx context.Context, s logical.Storage, dbType, name st
 func DeleteDBConfig(ctx context.Context, s logical.Storage, dbType, name string) error {
 	return s.Delete(ctx, ConfigPath(dbType, name))
 }
+
+// ConnectionPath is the storage key for a named connection config.
+func ConnectionPath(name string) string {
+	return fmt.Sprintf("config/%s", name)
+}
+
+// LoadConnection returns the stored connection config, or nil if it does not exist.
+func LoadConnection(ctx context.Context, s logical.Storage, name string) (*DatabaseConfig, error) {
+	entry, err := s.Get(ctx, ConnectionPath(name))
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	var conf DatabaseConfig
+	if err := entry.DecodeJSON(&conf); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
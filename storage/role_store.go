@@ -9,20 +9,23 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
-func rolePath(dbType, name string) string {
-	return fmt.Sprintf("roles/%s/%s", dbType, name)
+// RolePrefix is the storage prefix under which dynamic roles live.
+const RolePrefix = "roles/"
+
+func rolePath(name string) string {
+	return fmt.Sprintf("%s%s", RolePrefix, name)
 }
 
-func SaveRole(ctx context.Context, s logical.Storage, dbType, name string, r *role.RoleEntry) error {
-	entry, err := logical.StorageEntryJSON(rolePath(dbType, name), r)
+func SaveRole(ctx context.Context, s logical.Storage, name string, r *role.RoleEntry) error {
+	entry, err := logical.StorageEntryJSON(rolePath(name), r)
 	if err != nil {
 		return err
 	}
 	return s.Put(ctx, entry)
 }
 
-func LoadRole(ctx context.Context, s logical.Storage, dbType, name string) (*role.RoleEntry, error) {
-	entry, err := s.Get(ctx, rolePath(dbType, name))
+func LoadRole(ctx context.Context, s logical.Storage, name string) (*role.RoleEntry, error) {
+	entry, err := s.Get(ctx, rolePath(name))
 	if err != nil || entry == nil {
 		return nil, err
 	}
@@ -33,6 +36,6 @@ func LoadRole(ctx context.Context, s logical.Storage, dbType, name string) (*rol
 	return &r, nil
 }
 
-func DeleteRole(ctx context.Context, s logical.Storage, dbType, name string) error {
-	return s.Delete(ctx, rolePath(dbType, name))
+func DeleteRole(ctx context.Context, s logical.Storage, name string) error {
+	return s.Delete(ctx, rolePath(name))
 }
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"DatabasePluginVault/internal/notify"
+	"context"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// SMTPConfigPath is the storage key for the notification mail settings.
+const SMTPConfigPath = "smtp/config"
+
+func SaveSMTPConfig(ctx context.Context, s logical.Storage, c *notify.SMTPConfig) error {
+	entry, err := logical.StorageEntryJSON(SMTPConfigPath, c)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// LoadSMTPConfig returns the stored mail settings, or nil if notifications
+// are not configured.
+func LoadSMTPConfig(ctx context.Context, s logical.Storage) (*notify.SMTPConfig, error) {
+	entry, err := s.Get(ctx, SMTPConfigPath)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	var c notify.SMTPConfig
+	if err := entry.DecodeJSON(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func DeleteSMTPConfig(ctx context.Context, s logical.Storage) error {
+	return s.Delete(ctx, SMTPConfigPath)
+}